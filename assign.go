@@ -0,0 +1,270 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// kvLine is a key value pair parsed from the line of a config file
+type kvLine struct {
+	key   string
+	value string
+	line  int
+}
+
+// loadPaths sets values to the dstCfg, keys of the lines are paths like StructVal.SliceVal[0].IntVal,
+// keys without matched fields are ignored unless the ctx is strict
+func loadPaths(ctx context.Context, format, name string, lines []*kvLine, dstCfg interface{}) error {
+	v := reflect.ValueOf(dstCfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("gocfg: dstCfg must be a non-nil pointer")
+	}
+	strategy := keyStrategyOf(ctx)
+	unknownKeys, deprecatedKeys := []*UnknownKey{}, []*DeprecatedKey{}
+	for _, line := range lines {
+		segments, err := SplitPath(line.key)
+		if err != nil {
+			return fmt.Errorf("gocfg: %s:%d: invalid key %s", name, line.line, line.key)
+		}
+		set, err := setByPath(v.Elem(), segments, line.value, strategy, "")
+		if err != nil {
+			return &ParseError{Format: format, File: name, Line: line.line, Path: set.path, Err: err}
+		}
+		if set == nil && isStrict(ctx) {
+			unknownKeys = append(unknownKeys, &UnknownKey{
+				File:       name,
+				Line:       line.line,
+				Path:       unknownPath(v.Type(), segments, strategy),
+				Suggestion: suggest(line.key, knownPaths(v.Type(), strategy)),
+			})
+		}
+		if set != nil && set.message != "" {
+			deprecatedKeys = append(deprecatedKeys, &DeprecatedKey{
+				File:    name,
+				Line:    line.line,
+				Key:     line.key,
+				Path:    set.deprecatedPath,
+				Message: set.message,
+			})
+		}
+	}
+	if err := unknownKeysErr(unknownKeys); err != nil {
+		return err
+	}
+	return reportDeprecated(ctx, deprecatedKeys)
+}
+
+// assignment is the value in a template set by a key in a flat config
+type assignment struct {
+	path           string // path of the value in the template
+	deprecatedPath string // path of the outermost deprecated field named by the key
+	message        string // message of the deprecated field
+}
+
+// deprecate records the field named by the key at the path if it is deprecated,
+// fields are visited from the innermost, so the outermost deprecated one is kept
+func (set *assignment) deprecate(field reflect.StructField, key, path string) {
+	if msg, ok := deprecation(field, key, path); ok {
+		set.deprecatedPath, set.message = path, msg
+	}
+}
+
+// setByPath sets val to the value in v named by the path segments, e.g. StructVal, SliceVal, 0, IntVal,
+// struct fields are matched case-insensitively by any of their names in fieldNames and named by the strategy in paths,
+// it returns nil if nothing is named by the segments
+func setByPath(v reflect.Value, segments []string, val string, strategy KeyStrategy, path string) (*assignment, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			elem := reflect.New(v.Type().Elem())
+			set, err := setByPath(elem.Elem(), segments, val, strategy, path)
+			if set != nil && err == nil {
+				v.Set(elem)
+			}
+			return set, err
+		}
+		return setByPath(v.Elem(), segments, val, strategy, path)
+	case reflect.Struct:
+		if len(segments) == 0 {
+			return nil, nil
+		}
+		field, ok := matchField(v.Type(), segments[0])
+		if !ok {
+			return nil, nil
+		}
+		childPath := fieldPath(path, strategy.fieldName(field))
+		set, err := setByPath(v.FieldByIndex(field.Index), segments[1:], val, strategy, childPath)
+		if set != nil {
+			set.deprecate(field, segments[0], childPath)
+		}
+		return set, err
+	case reflect.Slice, reflect.Array:
+		if len(segments) == 0 {
+			return nil, nil
+		}
+		idx, err := strconv.Atoi(segments[0])
+		if err != nil || idx < 0 {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Array {
+			if idx >= v.Len() {
+				return nil, nil
+			}
+			return setByPath(v.Index(idx), segments[1:], val, strategy, indexPath(path, idx))
+		}
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if idx < v.Len() {
+			elem.Set(v.Index(idx))
+		}
+		set, err := setByPath(elem, segments[1:], val, strategy, indexPath(path, idx))
+		if set != nil && err == nil {
+			for v.Len() <= idx {
+				v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+			}
+			v.Index(idx).Set(elem)
+		}
+		return set, err
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || len(segments) == 0 {
+			return nil, nil
+		}
+		keyVal := reflect.ValueOf(segments[0]).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(keyVal); existing.IsValid() {
+			elem.Set(existing)
+		}
+		set, err := setByPath(elem, segments[1:], val, strategy, keyPath(path, segments[0]))
+		if set != nil && err == nil {
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			v.SetMapIndex(keyVal, elem)
+		}
+		return set, err
+	}
+
+	if len(segments) > 0 || !isScalar(v.Type()) {
+		return nil, nil
+	}
+	return &assignment{path: path}, setScalar(v, val)
+}
+
+// matchField returns the exported field in the struct type t named by the key case-insensitively by any of its names
+func matchField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		for _, name := range fieldNames(field) {
+			if strings.EqualFold(name, key) {
+				return field, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// unknownPath returns the path of the segments in the type t like setByPath,
+// matched fields are named by the strategy and the segments matching nothing are kept as they are
+func unknownPath(t reflect.Type, segments []string, strategy KeyStrategy) string {
+	path := ""
+	for i, segment := range segments {
+		switch t = derefType(t); t.Kind() {
+		case reflect.Struct:
+			if field, ok := matchField(t, segment); ok {
+				path, t = fieldPath(path, strategy.fieldName(field)), field.Type
+				continue
+			}
+		case reflect.Slice, reflect.Array:
+			if idx, err := strconv.Atoi(segment); err == nil && idx >= 0 {
+				path, t = indexPath(path, idx), t.Elem()
+				continue
+			}
+		case reflect.Map:
+			path, t = keyPath(path, segment), t.Elem()
+			continue
+		}
+
+		for _, rest := range segments[i:] {
+			path = fieldPath(path, rest)
+		}
+		break
+	}
+	return path
+}
+
+// fieldNames returns all names a field may be called in config files, aliases are included
+func fieldNames(field reflect.StructField) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range append([]string{
+		field.Name,
+		tagName(field, "json"),
+		tagName(field, "yaml"),
+		toSnakeCase(field.Name),
+	}, fieldAliases(field)...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// setScalar converts the string to the kind of v and sets it to v
+func setScalar(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		val, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("expected bool, got %q", s)
+		}
+		v.SetBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected int, got %q", s)
+		}
+		v.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected uint, got %q", s)
+		}
+		v.SetUint(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected float, got %q", s)
+		}
+		v.SetFloat(val)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return fmt.Errorf("kind %s is not supported", v.Kind())
+		}
+		v.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("kind %s is not supported", v.Kind())
+	}
+	return nil
+}
+
+// isScalar checks if the type can be set by setScalar
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Interface,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -1,12 +1,14 @@
 package gocfg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 )
 
 var GocfgTag = "cfg"
@@ -53,17 +55,35 @@ type ICfg interface {
 	SetString(key string, val string)
 	SetStruct(key string, val interface{})
 
+	Has(key string) bool
+	TypeOf(key string) Kind
+	AllKeys() []string
+	Walk(fn func(path string, kind Kind, value interface{}) error) error
+
 	Print()
 	Debug()
 	ToString() string
 	JSON() (string, error)
+	YAML() (string, error)
+	TOML() (string, error)
+	Dotenv(prefix string) (string, error)
 	Template() interface{}
 }
 
 // Cfg is an abstraction over a configuration
 type Cfg struct {
-	debug    bool
-	template interface{}
+	debug          bool
+	logger         Logger
+	loaded         bool
+	template       interface{}
+	keyStrategy    KeyStrategy
+	normalizeKeys  bool
+	normalizedKeys map[string][]string
+	secrets        map[string]bool
+	keySource      KeySource
+	strict         bool
+	migrations     *Migrations
+	writeMigrated  bool
 
 	boolVals   map[string]bool
 	intVals    map[string]int
@@ -80,49 +100,116 @@ type valueInfo struct {
 	name string
 }
 
+// Option configures a Cfg in New
+type Option func(c *Cfg)
+
+// sortedMapKeys returns keys of a string keyed map in order
+func sortedMapKeys(mapVal reflect.Value) []reflect.Value {
+	keys := mapVal.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
 // New returns a new *Cfg
-func New(template interface{}) *Cfg {
-	return &Cfg{
-		debug:      false,
-		template:   template,
-		boolVals:   map[string]bool{},
-		intVals:    map[string]int{},
-		floatVals:  map[string]float64{},
-		stringVals: map[string]string{},
-		mapVals:    map[string]interface{}{},
-		sliceVals:  map[string]interface{}{},
-		structVals: map[string]interface{}{},
+func New(template interface{}, opts ...Option) *Cfg {
+	c := &Cfg{
+		debug:          false,
+		template:       template,
+		keyStrategy:    GoFieldKeys,
+		normalizeKeys:  false,
+		normalizedKeys: map[string][]string{},
+		secrets:        map[string]bool{},
+		boolVals:       map[string]bool{},
+		intVals:        map[string]int{},
+		floatVals:      map[string]float64{},
+		stringVals:     map[string]string{},
+		mapVals:        map[string]interface{}{},
+		sliceVals:      map[string]interface{}{},
+		structVals:     map[string]interface{}{},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// Debug opens debug mode and prints more logs.
+// Debug opens debug mode and prints more logs to stderr unless a logger is set by WithLogger.
 func (c *Cfg) Debug() {
 	c.debug = true
 }
 
 // Load loads configuration from local path according to config's definition
 func (c *Cfg) Load(pvds ...CfgProvider) (*Cfg, error) {
-	var err error
+	ctxPvds := []ContextProvider{}
 	for _, pvd := range pvds {
-		if err = pvd.Load(c.template); err != nil {
-			return nil, err
-		}
-		if err = c.visit(c.template); err != nil {
+		ctxPvds = append(ctxPvds, Contextual(pvd))
+	}
+	return c.LoadContext(context.Background(), ctxPvds...)
+}
+
+// LoadContext loads configuration like Load, and loading is canceled once the ctx is done
+func (c *Cfg) LoadContext(ctx context.Context, pvds ...ContextProvider) (*Cfg, error) {
+	if c.strict {
+		ctx = withStrict(ctx)
+	}
+	ctx = withKeyStrategy(ctx, c.keyStrategy)
+	var m *migrator
+	if c.migrations != nil {
+		m = newMigrator(c.migrations, c.writeMigrated)
+		ctx = withMigrations(ctx, m)
+	}
+	if err := c.load(ctx, pvds); err != nil {
+		c.log().Error("load failed", "error", err)
+		return nil, err
+	}
+	if m != nil {
+		// migrated files are written only if the config is loaded
+		if err := m.flush(); err != nil {
+			c.log().Error("load failed", "error", err)
 			return nil, err
 		}
 	}
+
+	if c.loaded {
+		c.log().Info("config reloaded", "providers", len(pvds))
+	} else {
+		c.log().Info("config loaded", "providers", len(pvds))
+	}
+	c.loaded = true
 	return c, nil
 }
 
-func (c *Cfg) warnf(format string, vals ...interface{}) {
-	if c.debug {
-		fmt.Printf(format, vals...)
+func (c *Cfg) load(ctx context.Context, pvds []ContextProvider) error {
+	for _, pvd := range pvds {
+		found := &deprecations{mtx: &sync.Mutex{}, keys: []*DeprecatedKey{}}
+		result, err := loadReported(withDeprecations(ctx, found), pvd, c.template)
+		if err != nil {
+			return err
+		}
+		c.log().Info("provider loaded", "provider", describe(pvd), "result", result)
+		for _, key := range found.keys {
+			c.log().Warn(
+				"deprecated key",
+				"source", key.Source(),
+				"key", key.Key,
+				"path", key.Path,
+				"message", key.Message,
+			)
+		}
+		if err := c.decryptValues(); err != nil {
+			return err
+		}
+		if err := c.visit(c.template, describe(pvd)); err != nil {
+			return err
+		}
 	}
+	c.indexNormalizedKeys()
+	return c.validate(c.template)
 }
 
 // JSON returns all configs as a JSON in a string
 func (c *Cfg) JSON() (string, error) {
-	tpltBytes, err := json.Marshal(c.template)
+	tpltBytes, err := json.Marshal(c.masked(c.template, ""))
 	if err != nil {
 		return "", err
 	}
@@ -149,8 +236,11 @@ func (c *Cfg) ToString() string {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		v := c.boolVals[k]
-		rows = append(rows, fmt.Sprintf("%s:bool = %t", k, v))
+		v := fmt.Sprintf("%t", c.boolVals[k])
+		if c.isSecret(k) {
+			v = SecretMask
+		}
+		rows = append(rows, fmt.Sprintf("%s:bool = %s", k, v))
 	}
 	keys = keys[:0]
 
@@ -159,8 +249,11 @@ func (c *Cfg) ToString() string {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		v := c.intVals[k]
-		rows = append(rows, fmt.Sprintf("%s:int = %d", k, v))
+		v := fmt.Sprintf("%d", c.intVals[k])
+		if c.isSecret(k) {
+			v = SecretMask
+		}
+		rows = append(rows, fmt.Sprintf("%s:int = %s", k, v))
 	}
 	keys = keys[:0]
 
@@ -169,8 +262,11 @@ func (c *Cfg) ToString() string {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		v := c.floatVals[k]
-		rows = append(rows, fmt.Sprintf("%s:float = %f", k, v))
+		v := fmt.Sprintf("%f", c.floatVals[k])
+		if c.isSecret(k) {
+			v = SecretMask
+		}
+		rows = append(rows, fmt.Sprintf("%s:float = %s", k, v))
 	}
 	keys = keys[:0]
 
@@ -180,6 +276,9 @@ func (c *Cfg) ToString() string {
 	sort.Strings(keys)
 	for _, k := range keys {
 		v := c.stringVals[k]
+		if c.isSecret(k) {
+			v = SecretMask
+		}
 		rows = append(rows, fmt.Sprintf("%s:string = %s", k, v))
 	}
 	keys = keys[:0]
@@ -189,7 +288,7 @@ func (c *Cfg) ToString() string {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		mv := c.mapVals[k]
+		mv := c.masked(c.mapVals[k], k)
 		mvBytes, err := json.Marshal(mv)
 		if err != nil {
 			panic(err)
@@ -203,7 +302,7 @@ func (c *Cfg) ToString() string {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		sv := c.sliceVals[k]
+		sv := c.masked(c.sliceVals[k], k)
 		svBytes, err := json.Marshal(sv)
 		if err != nil {
 			panic(err)
@@ -217,7 +316,7 @@ func (c *Cfg) ToString() string {
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
-		sv := c.structVals[k]
+		sv := c.masked(c.structVals[k], k)
 		svBytes, err := json.Marshal(sv)
 		if err != nil {
 			panic(err)
@@ -229,7 +328,7 @@ func (c *Cfg) ToString() string {
 	return strings.Join(rows, "\n")
 }
 
-func (c *Cfg) visit(cfgObj interface{}) error {
+func (c *Cfg) visit(cfgObj interface{}, source string) error {
 	queue := []*valueInfo{}
 	queue = append(
 		queue,
@@ -247,25 +346,47 @@ func (c *Cfg) visit(cfgObj interface{}) error {
 		k := e.v.Kind()
 		switch {
 		case k == reflect.Bool:
+			if old, ok := c.boolVals[e.path]; ok && old != e.v.Bool() {
+				c.log().Info("value overridden", "path", e.path, "provider", source)
+			}
 			c.boolVals[e.path] = e.v.Bool()
 		case k == reflect.Int:
+			if old, ok := c.intVals[e.path]; ok && old != e.v.Interface().(int) {
+				c.log().Info("value overridden", "path", e.path, "provider", source)
+			}
 			c.intVals[e.path] = e.v.Interface().(int) // use int instead of uint/int/8/16/32/64
 		case k == reflect.Float64:
+			if old, ok := c.floatVals[e.path]; ok && old != e.v.Float() {
+				c.log().Info("value overridden", "path", e.path, "provider", source)
+			}
 			c.floatVals[e.path] = e.v.Float()
 		case k == reflect.String:
+			if old, ok := c.stringVals[e.path]; ok && old != e.v.String() {
+				c.log().Info("value overridden", "path", e.path, "provider", source)
+			}
 			c.stringVals[e.path] = e.v.String()
 		case k == reflect.Map:
+			mapVal := e.v
+			// maps with values which can not be indexed are kept as a whole, e.g. map[string]int64
+			if mapVal.Type().Key().Kind() == reflect.String && isIndexable(mapVal) {
+				for _, key := range sortedMapKeys(mapVal) {
+					childName := key.String()
+					info := &valueInfo{
+						v:    mapVal.MapIndex(key),
+						name: childName,
+						path: keyPath(e.path, childName),
+					}
+					queue = append(queue, info)
+				}
+			}
+			// also set the whole map as a config value
 			c.mapVals[e.path] = e.v.Interface()
 		case k == reflect.Slice:
 			sliceVal := e.v
 			for i := 0; i < sliceVal.Len(); i++ {
 				childName := fmt.Sprintf("%d", i)
 				childValue := sliceVal.Index(i)
-				childPath := fmt.Sprintf("%s[%s]", e.path, childName)
-				if e.path == "" {
-					// actually the root should not be an array
-					childPath = childName
-				}
+				childPath := indexPath(e.path, i)
 				info := &valueInfo{
 					v:    childValue,
 					name: childName,
@@ -278,17 +399,28 @@ func (c *Cfg) visit(cfgObj interface{}) error {
 		case k == reflect.Struct:
 			structVal := e.v
 			for i := 0; i < structVal.NumField(); i++ {
-				childName := structVal.Type().Field(i).Name
-				childValue := structVal.Field(i)
-				childPath := fmt.Sprintf("%s.%s", e.path, childName)
-				if e.path == "" {
-					childPath = childName
+				if structVal.Type().Field(i).PkgPath != "" {
+					// unexported
+					continue
 				}
+				childName := c.fieldName(structVal.Type().Field(i))
+				childValue := structVal.Field(i)
+				childPath := fieldPath(e.path, childName)
 
 				// check if it should be retrieved from env
-				tagValue := structVal.Type().Field(i).Tag.Get(GocfgTag)
+				// aliases are excluded as they may contain other options, e.g. alias=Environment
+				tagValue := ""
+				for _, opt := range cfgTagOptions(structVal.Type().Field(i)) {
+					if !strings.HasPrefix(opt, GocfgValAlias) {
+						tagValue = fmt.Sprintf("%s,%s", tagValue, opt)
+					}
+				}
 				isEnv := strings.Contains(tagValue, GocfgValEnv)
 				isRequired := strings.Contains(tagValue, GocfgValRequired)
+				isSecret := strings.Contains(tagValue, GocfgValSecret)
+				if isSecret {
+					c.secrets[childPath] = true
+				}
 				envName := strings.ToUpper(childName)
 
 				if isEnv {
@@ -298,6 +430,9 @@ func (c *Cfg) visit(cfgObj interface{}) error {
 					}
 					// set the value even it does not exist
 					c.stringVals[fmt.Sprintf("ENV.%s", envName)] = envValue
+					if isSecret {
+						c.secrets[fmt.Sprintf("ENV.%s", envName)] = true
+					}
 				}
 
 				// also set the config value accodingly
@@ -310,7 +445,7 @@ func (c *Cfg) visit(cfgObj interface{}) error {
 			}
 			// also set the whole struct as a config value
 			c.structVals[e.path] = e.v.Interface()
-		case k == reflect.Ptr:
+		case k == reflect.Ptr || k == reflect.Interface:
 			info := &valueInfo{
 				v:    e.v.Elem(),
 				name: e.name,
@@ -319,7 +454,7 @@ func (c *Cfg) visit(cfgObj interface{}) error {
 			queue = append(queue, info)
 		case k == reflect.Invalid:
 			if !e.v.IsValid() {
-				c.warnf("gocfg: warning: %s(kind=%s) is zero value\n", e.path, k)
+				c.log().Debug("zero value", "path", e.path, "kind", k)
 				// no op if the field is nil
 				// From go doc:
 				// IsValid reports whether v represents a value.
@@ -334,58 +469,97 @@ func (c *Cfg) visit(cfgObj interface{}) error {
 				return fmt.Errorf("gocfg: warning: %s(kind=%s) is invalid value", e.path, k)
 			}
 		default:
-			return fmt.Errorf("gocfg: %s(kind=%s) is not supproted", e.path, k)
+			return fmt.Errorf("gocfg: %s(kind=%s) is not supported", e.path, k)
 		}
 	}
 
 	return nil
 }
 
+// isIndexable checks if all values in v can be indexed by visit
+func isIndexable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Bool, reflect.Int, reflect.Float64, reflect.String:
+		return true
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil() || isIndexable(v.Elem())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if !isIndexable(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return true
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if !isIndexable(iter.Value()) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if !isIndexable(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 // Bool get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) Bool(key string) (bool, bool) {
-	val, ok := c.boolVals[key]
+	val, ok := c.boolVals[c.resolve(key)]
 	return val, ok
 }
 
 // Int get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) Int(key string) (int, bool) {
-	val, ok := c.intVals[key]
+	val, ok := c.intVals[c.resolve(key)]
 	return val, ok
 }
 
 // Float get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) Float(key string) (float64, bool) {
-	val, ok := c.floatVals[key]
+	val, ok := c.floatVals[c.resolve(key)]
 	return val, ok
 }
 
 // String get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) String(key string) (string, bool) {
-	val, ok := c.stringVals[key]
+	val, ok := c.stringVals[c.resolve(key)]
 	return val, ok
 }
 
 // Map get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) Map(key string) (interface{}, bool) {
-	val, ok := c.mapVals[key]
+	val, ok := c.mapVals[c.resolve(key)]
 	return val, ok
 }
 
 // Slice get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) Slice(key string) (interface{}, bool) {
-	val, ok := c.sliceVals[key]
+	val, ok := c.sliceVals[c.resolve(key)]
 	return val, ok
 }
 
 // Struct get a configuration value according to key, the second returned value is false if nothing not found.
 func (c *Cfg) Struct(key string) (interface{}, bool) {
-	val, ok := c.structVals[key]
+	val, ok := c.structVals[c.resolve(key)]
 	return val, ok
 }
 
 // BoolOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) BoolOr(key string, defaultVal bool) bool {
-	val, ok := c.boolVals[key]
+	val, ok := c.boolVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -394,7 +568,7 @@ func (c *Cfg) BoolOr(key string, defaultVal bool) bool {
 
 // IntOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) IntOr(key string, defaultVal int) int {
-	val, ok := c.intVals[key]
+	val, ok := c.intVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -403,7 +577,7 @@ func (c *Cfg) IntOr(key string, defaultVal int) int {
 
 // FloatOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) FloatOr(key string, defaultVal float64) float64 {
-	val, ok := c.floatVals[key]
+	val, ok := c.floatVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -412,7 +586,7 @@ func (c *Cfg) FloatOr(key string, defaultVal float64) float64 {
 
 // StringOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) StringOr(key string, defaultVal string) string {
-	val, ok := c.stringVals[key]
+	val, ok := c.stringVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -421,7 +595,7 @@ func (c *Cfg) StringOr(key string, defaultVal string) string {
 
 // MapOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) MapOr(key string, defaultVal interface{}) interface{} {
-	val, ok := c.mapVals[key]
+	val, ok := c.mapVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -430,7 +604,7 @@ func (c *Cfg) MapOr(key string, defaultVal interface{}) interface{} {
 
 // SliceOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) SliceOr(key string, defaultVal interface{}) interface{} {
-	val, ok := c.sliceVals[key]
+	val, ok := c.sliceVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -439,7 +613,7 @@ func (c *Cfg) SliceOr(key string, defaultVal interface{}) interface{} {
 
 // StructOr get a configuration value according to the key, or it returns the defaultVal instead.
 func (c *Cfg) StructOr(key string, defaultVal interface{}) interface{} {
-	val, ok := c.structVals[key]
+	val, ok := c.structVals[c.resolve(key)]
 	if ok {
 		return val
 	}
@@ -447,46 +621,46 @@ func (c *Cfg) StructOr(key string, defaultVal interface{}) interface{} {
 }
 
 // GrabBool get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabBool(key string) bool { return c.boolVals[key] }
+func (c *Cfg) GrabBool(key string) bool { return c.boolVals[c.resolve(key)] }
 
 // GrabInt get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabInt(key string) int { return c.intVals[key] }
+func (c *Cfg) GrabInt(key string) int { return c.intVals[c.resolve(key)] }
 
 // GrabFloat get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabFloat(key string) float64 { return c.floatVals[key] }
+func (c *Cfg) GrabFloat(key string) float64 { return c.floatVals[c.resolve(key)] }
 
 // GrabString get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabString(key string) string { return c.stringVals[key] }
+func (c *Cfg) GrabString(key string) string { return c.stringVals[c.resolve(key)] }
 
 // GrabMap get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabMap(key string) interface{} { return c.mapVals[key] }
+func (c *Cfg) GrabMap(key string) interface{} { return c.mapVals[c.resolve(key)] }
 
 // GrabSlice get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabSlice(key string) interface{} { return c.sliceVals[key] }
+func (c *Cfg) GrabSlice(key string) interface{} { return c.sliceVals[c.resolve(key)] }
 
 // GrabStruct get a configuration value according to the key, it returns zero value if no value is found.
-func (c *Cfg) GrabStruct(key string) interface{} { return c.structVals[key] }
+func (c *Cfg) GrabStruct(key string) interface{} { return c.structVals[c.resolve(key)] }
 
 // SetBool set val in Cfg according to the key.
-func (c *Cfg) SetBool(key string, val bool) { c.boolVals[key] = val }
+func (c *Cfg) SetBool(key string, val bool) { c.boolVals[c.resolve(key)] = val }
 
 // SetInt set val in Cfg according to the key.
-func (c *Cfg) SetInt(key string, val int) { c.intVals[key] = val }
+func (c *Cfg) SetInt(key string, val int) { c.intVals[c.resolve(key)] = val }
 
 // SetFloat set val in Cfg according to the key.
-func (c *Cfg) SetFloat(key string, val float64) { c.floatVals[key] = val }
+func (c *Cfg) SetFloat(key string, val float64) { c.floatVals[c.resolve(key)] = val }
 
 // SetString set val in Cfg according to the key.
-func (c *Cfg) SetString(key string, val string) { c.stringVals[key] = val }
+func (c *Cfg) SetString(key string, val string) { c.stringVals[c.resolve(key)] = val }
 
 // SetMap set val in Cfg according to the key.
-func (c *Cfg) SetMap(key string, val interface{}) { c.mapVals[key] = val }
+func (c *Cfg) SetMap(key string, val interface{}) { c.mapVals[c.resolve(key)] = val }
 
 // SetSlice set val in Cfg according to the key.
-func (c *Cfg) SetSlice(key string, val interface{}) { c.sliceVals[key] = val }
+func (c *Cfg) SetSlice(key string, val interface{}) { c.sliceVals[c.resolve(key)] = val }
 
 // SetStruct set val in Cfg according to the key.
-func (c *Cfg) SetStruct(key string, val interface{}) { c.structVals[key] = val }
+func (c *Cfg) SetStruct(key string, val interface{}) { c.structVals[c.resolve(key)] = val }
 
 func (c *Cfg) Bools() map[string]bool {
 	return c.boolVals
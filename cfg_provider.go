@@ -1,11 +1,13 @@
 package gocfg
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
-
-	yaml "gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
 )
 
 // CfgProvider is a configuration loader interface
@@ -25,7 +27,12 @@ func JSONStr(content string) *JSONStrCfg {
 
 // Load populates content according to the definition of the dstCfg
 func (cfg *JSONStrCfg) Load(dstCfg interface{}) error {
-	return json.Unmarshal([]byte(cfg.content), dstCfg)
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates content like Load with options in the ctx
+func (cfg *JSONStrCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	return decode(ctx, FormatJSON, "json", []byte(cfg.content), dstCfg)
 }
 
 // JSONCfg is a configuration loader for a local json file
@@ -38,19 +45,23 @@ func JSON(path string) *JSONCfg {
 	return &JSONCfg{path: path}
 }
 
+// Source returns the path of the json file
+func (cfg *JSONCfg) Source() string {
+	return cfg.path
+}
+
 // Load populates json file according to the definition of the dstCfg
 func (cfg *JSONCfg) Load(dstCfg interface{}) error {
-	cfgFile, err := os.Open(cfg.path)
-	if err != nil {
-		return err
-	}
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
 
-	cfgBytes, err := ioutil.ReadAll(cfgFile)
+// LoadContext populates json file like Load with options in the ctx
+func (cfg *JSONCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadFile(cfg.path)
 	if err != nil {
 		return err
 	}
-
-	return json.Unmarshal(cfgBytes, dstCfg)
+	return decode(withLocalFile(ctx), FormatJSON, cfg.path, cfgBytes, dstCfg)
 }
 
 // YAMLCfg is a configuration loader for a local yaml file
@@ -63,19 +74,23 @@ func YAML(path string) *YAMLCfg {
 	return &YAMLCfg{path: path}
 }
 
+// Source returns the path of the yaml file
+func (cfg *YAMLCfg) Source() string {
+	return cfg.path
+}
+
 // Load populates yaml file according to the definition of the dstCfg
 func (cfg *YAMLCfg) Load(dstCfg interface{}) error {
-	cfgFile, err := os.Open(cfg.path)
-	if err != nil {
-		return err
-	}
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
 
-	cfgBytes, err := ioutil.ReadAll(cfgFile)
+// LoadContext populates yaml file like Load with options in the ctx
+func (cfg *YAMLCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadFile(cfg.path)
 	if err != nil {
 		return err
 	}
-
-	return yaml.Unmarshal(cfgBytes, dstCfg)
+	return decode(withLocalFile(ctx), FormatYAML, cfg.path, cfgBytes, dstCfg)
 }
 
 // YAMLStrCfg is a configuration loader for a local yaml file
@@ -90,7 +105,62 @@ func YAMLStr(content string) *YAMLStrCfg {
 
 // Load populates yaml file according to the definition of the dstCfg
 func (cfg *YAMLStrCfg) Load(dstCfg interface{}) error {
-	return yaml.Unmarshal([]byte(cfg.content), dstCfg)
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates content like Load with options in the ctx
+func (cfg *YAMLStrCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	return decode(ctx, FormatYAML, "yaml", []byte(cfg.content), dstCfg)
+}
+
+// XMLCfg is a configuration loader for a local xml file
+type XMLCfg struct {
+	path string
+}
+
+// XML inits an XMLCfg according to the xml file in the path,
+// fields are matched by their xml tags or names, and repeated elements are appended to slices
+func XML(path string) *XMLCfg {
+	return &XMLCfg{path: path}
+}
+
+// Source returns the path of the xml file
+func (cfg *XMLCfg) Source() string {
+	return cfg.path
+}
+
+// Load populates xml file according to the definition of the dstCfg
+func (cfg *XMLCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates xml file like Load with options in the ctx
+func (cfg *XMLCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadFile(cfg.path)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, FormatXML, cfg.path, cfgBytes, dstCfg)
+}
+
+// XMLStrCfg is a configuration loader for a xml string
+type XMLStrCfg struct {
+	content string
+}
+
+// XMLStr inits a XMLStrCfg according to the content
+func XMLStr(content string) *XMLStrCfg {
+	return &XMLStrCfg{content: content}
+}
+
+// Load populates content according to the definition of the dstCfg
+func (cfg *XMLStrCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates content like Load with options in the ctx
+func (cfg *XMLStrCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	return decode(ctx, FormatXML, "xml", []byte(cfg.content), dstCfg)
 }
 
 // GoCfgCfg is a configuration loader for a gocfg struct
@@ -105,9 +175,88 @@ func GoCfg(srcCfg *Cfg) *GoCfgCfg {
 
 // Load populates gocfg struct and save to
 func (cfg *GoCfgCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates gocfg struct like Load with options in the ctx
+func (cfg *GoCfgCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
 	cfgBytes, err := json.Marshal(cfg.srcCfg.template)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(cfgBytes, dstCfg)
+	return decode(ctx, FormatJSON, "gocfg", cfgBytes, dstCfg)
+}
+
+// GocfgProfileEnv is the environment variable used to choose a profile when no profile is given
+var GocfgProfileEnv = "APP_ENV"
+
+// ProfileCfg is a configuration loader for a base file and an optional profile overlay,
+// e.g. app.yaml and then app.prod.yaml
+type ProfileCfg struct {
+	base    string
+	profile string
+}
+
+// Profile inits a ProfileCfg according to the base file in the path,
+// the profile is read from the GocfgProfileEnv environment variable if it is empty
+func Profile(base, profile string) *ProfileCfg {
+	if profile == "" {
+		profile = os.Getenv(GocfgProfileEnv)
+	}
+	return &ProfileCfg{base: base, profile: profile}
+}
+
+// Source returns the path of the base file
+func (cfg *ProfileCfg) Source() string {
+	return cfg.base
+}
+
+// Active returns the name of the active profile, it is empty if no profile is chosen
+func (cfg *ProfileCfg) Active() string {
+	return cfg.profile
+}
+
+// OverlayPath returns the path of the overlay file of the active profile
+func (cfg *ProfileCfg) OverlayPath() string {
+	if cfg.profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(cfg.base)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(cfg.base, ext), cfg.profile, ext)
+}
+
+// Load populates the base file and then the overlay file of the active profile,
+// the overlay is skipped if it does not exist
+func (cfg *ProfileCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates the base file and the overlay like Load with options in the ctx
+func (cfg *ProfileCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	if err := Contextual(fileProvider(cfg.base)).Load(ctx, dstCfg); err != nil {
+		return err
+	}
+
+	overlayPath := cfg.OverlayPath()
+	if overlayPath == "" {
+		return nil
+	}
+	if _, err := os.Stat(overlayPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return Contextual(fileProvider(overlayPath)).Load(ctx, dstCfg)
+}
+
+// fileProvider picks a provider for the file in the path according to its extension
+func fileProvider(path string) CfgProvider {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON(path)
+	case ".xml":
+		return XML(path)
+	}
+	return YAML(path)
 }
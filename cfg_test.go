@@ -3,8 +3,11 @@ package gocfg
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -337,3 +340,230 @@ func newNodeTree(id, size int) *testConfig {
 		StructVal: structNode,
 	}
 }
+
+func TestProfile(t *testing.T) {
+	type config struct {
+		Host string `json:"host" yaml:"host"`
+		Port int    `json:"port" yaml:"port"`
+	}
+
+	dir, err := ioutil.TempDir("", "gocfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"app.yaml":      "host: localhost\nport: 80\n",
+		"app.prod.yaml": "host: example.com\n",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := filepath.Join(dir, "app.yaml")
+
+	t.Run("overlay the profile", func(t *testing.T) {
+		pvd := Profile(base, "prod")
+		if pvd.Active() != "prod" {
+			t.Fatalf("active profile not match: expected: prod, got: %s", pvd.Active())
+		}
+
+		cfg, err := New(&config{}).Load(pvd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GrabString("Host") != "example.com" {
+			t.Fatalf("host not match: expected: example.com, got: %s", cfg.GrabString("Host"))
+		}
+		if cfg.GrabInt("Port") != 80 {
+			t.Fatalf("port not match: expected: 80, got: %d", cfg.GrabInt("Port"))
+		}
+	})
+
+	t.Run("profile from env and missing overlay", func(t *testing.T) {
+		err := os.Setenv(GocfgProfileEnv, "dev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Unsetenv(GocfgProfileEnv)
+
+		pvd := Profile(base, "")
+		if pvd.Active() != "dev" {
+			t.Fatalf("active profile not match: expected: dev, got: %s", pvd.Active())
+		}
+
+		cfg, err := New(&config{}).Load(pvd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GrabString("Host") != "localhost" {
+			t.Fatalf("host not match: expected: localhost, got: %s", cfg.GrabString("Host"))
+		}
+	})
+}
+
+func TestMapEntries(t *testing.T) {
+	type config struct {
+		IntVal int                `json:"intVal"`
+		MapVal map[string]*config `json:"mapVal"`
+		Labels map[string]string  `json:"labels"`
+		Extra  interface{}        `json:"extra"`
+	}
+
+	input := `{
+		"mapVal": {
+			"foo": {"intVal": 1},
+			"foo.bar": {"intVal": 2, "mapVal": {"baz": {"intVal": 3}}}
+		},
+		"labels": {"team": "infra"},
+		"extra": {"enabled": true, "hosts": ["a", "b"]}
+	}`
+	cfg, err := New(&config{}).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ints := map[string]int{
+		"MapVal[foo].IntVal":                   1,
+		`MapVal["foo.bar"].IntVal`:             2,
+		`MapVal["foo.bar"].MapVal[baz].IntVal`: 3,
+	}
+	for key, val := range ints {
+		if cfg.IntOr(key, -1) != val {
+			t.Fatalf("key %s not match: expected: %d, got: %d", key, val, cfg.IntOr(key, -1))
+		}
+	}
+	if cfg.GrabString("Labels[team]") != "infra" {
+		t.Fatalf("key Labels[team] not match: expected: infra, got: %s", cfg.GrabString("Labels[team]"))
+	}
+	if !cfg.GrabBool("Extra[enabled]") {
+		t.Fatal("key Extra[enabled] not match: expected: true, got: false")
+	}
+	if cfg.GrabString("Extra[hosts][1]") != "b" {
+		t.Fatalf("key Extra[hosts][1] not match: expected: b, got: %s", cfg.GrabString("Extra[hosts][1]"))
+	}
+	if _, ok := cfg.Map("MapVal"); !ok {
+		t.Fatal("the whole map MapVal should be indexed")
+	}
+
+	// maps with values of unsupported kinds are kept as a whole
+	type opaqueConfig struct {
+		Int64Vals map[string]int64       `json:"int64Vals"`
+		UintVals  map[string][]uint      `json:"uintVals"`
+		Extra     map[string]interface{} `json:"extra"`
+	}
+	cfg, err = New(&opaqueConfig{}).Load(Reader(FormatTOML, strings.NewReader("[int64Vals]\na = 1\n[uintVals]\nb = [2]\n[extra]\nc = 3\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, ok := cfg.Map("Int64Vals"); !ok || val.(map[string]int64)["a"] != 1 {
+		t.Fatalf("map Int64Vals not match: got: %v", val)
+	}
+	if _, ok := cfg.Map("UintVals"); !ok || cfg.Has("Int64Vals[a]") || cfg.Has("Extra[c]") {
+		t.Fatalf("maps should not be indexed by entries: got: %s", cfg.ToString())
+	}
+
+	// unexported fields are skipped
+	type entry struct {
+		n int
+		S string
+	}
+	type unexportedConfig struct {
+		Entries map[string]entry `json:"entries"`
+	}
+	cfg, err = New(&unexportedConfig{Entries: map[string]entry{"a": {1, "x"}}}).Load(JSONStr(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Entries[a].S") != "x" || cfg.Has("Entries[a].n") {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+}
+
+func TestXML(t *testing.T) {
+	type server struct {
+		Host string `xml:"host,attr"`
+		Port int    `xml:"port"`
+	}
+	type config struct {
+		Name      string    `xml:"name"`
+		Debug     bool      `xml:"debug"`
+		Servers   []*server `xml:"servers>server"`
+		StructVal *server
+	}
+
+	input := `
+	<config>
+		<name>app</name>
+		<debug>true</debug>
+		<servers>
+			<server host="a.example.com"><port>80</port></server>
+			<server host="b.example.com"><port>81</port></server>
+		</servers>
+		<StructVal host="c.example.com"><port>82</port></StructVal>
+	</config>`
+	cfg, err := New(&config{}).Load(XMLStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strs := map[string]string{
+		"Name":            "app",
+		"Servers[0].Host": "a.example.com",
+		"Servers[1].Host": "b.example.com",
+		"StructVal.Host":  "c.example.com",
+	}
+	for key, val := range strs {
+		if cfg.GrabString(key) != val {
+			t.Fatalf("key %s not match: expected: %s, got: %s", key, val, cfg.GrabString(key))
+		}
+	}
+	ints := map[string]int{
+		"Servers[1].Port": 81,
+		"StructVal.Port":  82,
+	}
+	for key, val := range ints {
+		if cfg.GrabInt(key) != val {
+			t.Fatalf("key %s not match: expected: %d, got: %d", key, val, cfg.GrabInt(key))
+		}
+	}
+	if !cfg.GrabBool("Debug") {
+		t.Fatal("key Debug not match: expected: true, got: false")
+	}
+
+	path, cleanup := writeTempFile(t, "app.xml", "<config><port>abc</port></config>")
+	defer cleanup()
+	if _, err = New(&server{}).Load(XML(path)); err == nil {
+		t.Fatal("error should be returned")
+	}
+
+	// slices in the overlay replace the base ones, and others are kept
+	type overlayConfig struct {
+		Servers []*server `xml:"servers>server"`
+		Tags    []string  `xml:"tag"`
+	}
+	base := `
+	<config>
+		<servers>
+			<server host="a.example.com"><port>80</port></server>
+			<server host="b.example.com"><port>81</port></server>
+		</servers>
+		<tag>a</tag>
+		<tag>b</tag>
+	</config>`
+	overlay := `<config><servers><server host="c.example.com"><port>82</port></server></servers></config>`
+	cfg, err = New(&overlayConfig{}).Load(XMLStr(base), XMLStr(overlay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := cfg.GrabSlice("Servers").([]*server)
+	if len(servers) != 1 || servers[0].Host != "c.example.com" {
+		t.Fatalf("servers not match: expected: 1, got: %d", len(servers))
+	}
+	if tags := cfg.GrabSlice("Tags").([]string); len(tags) != 2 {
+		t.Fatalf("tags not match: expected: 2, got: %d", len(tags))
+	}
+}
@@ -0,0 +1,226 @@
+// Command gocfg converts, flattens and diffs config files without writing Go.
+//
+//	gocfg convert [-from format] -to format [file]
+//	gocfg flatten [-from format] [file]
+//	gocfg diff [-from format] file1 file2
+//
+// Flags can be placed before or after files, and arguments after "--" are files.
+// Formats are json, yaml, toml and dotenv, they are detected by extensions of files unless -from is set,
+// and the content is read from stdin if the file is "-" or missing.
+// Flattened keys are paths built from the raw keys in the documents, e.g. servers[0].host, not names of Go fields,
+// and diff exits with 1 if the files are different.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ihexxa/gocfg"
+)
+
+const usage = `usage:
+  gocfg convert [-from format] -to format [file]
+  gocfg flatten [-from format] [file]
+  gocfg diff [-from format] file1 file2
+flags can be placed before or after files, and arguments after -- are files
+formats: json, yaml, toml, dotenv`
+
+// errDiffer is returned by diff if the files are different
+var errDiffer = errors.New("files are different")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	switch {
+	case err == nil:
+	case errors.Is(err, errDiffer):
+		os.Exit(1)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+// run executes the command in the args, content is read from stdin for the file "-"
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	from := flags.String("from", "", "format of the input files")
+	to := flags.String("to", "", "format of the output")
+	files, err := parseFlags(flags, args[1:])
+	if err != nil {
+		return fmt.Errorf("%s\n%s", err, usage)
+	}
+
+	switch args[0] {
+	case "convert":
+		if *to == "" || len(files) > 1 {
+			return errors.New(usage)
+		}
+		doc, err := readDocument(orStdin(files), *from, stdin)
+		if err != nil {
+			return err
+		}
+		content, err := gocfg.EncodeDocument(*to, doc)
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(content)
+		return err
+	case "flatten":
+		if len(files) > 1 {
+			return errors.New(usage)
+		}
+		values, err := flatten(orStdin(files), *from, stdin)
+		if err != nil {
+			return err
+		}
+		for _, path := range sortedPaths(values) {
+			if _, err = fmt.Fprintf(stdout, "%s = %s\n", path, values[path]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "diff":
+		if len(files) != 2 {
+			return errors.New(usage)
+		}
+		if files[0] == "-" && files[1] == "-" {
+			return fmt.Errorf("stdin can not be read twice, only one file can be -\n%s", usage)
+		}
+		return diff(files[0], files[1], *from, stdin, stdout)
+	}
+	return fmt.Errorf("unknown command %s\n%s", args[0], usage)
+}
+
+// parseFlags parses flags before and after files, e.g. convert app.yaml -to json, and returns the files,
+// arguments after "--" are files
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	files := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			// parsing is stopped by "--"
+			return append(files, rest...), nil
+		}
+		if len(rest) == 0 {
+			return files, nil
+		}
+		files, args = append(files, rest[0]), rest[1:]
+	}
+}
+
+func orStdin(files []string) string {
+	if len(files) == 0 {
+		return "-"
+	}
+	return files[0]
+}
+
+// readDocument decodes the file in the format, which is detected by the extension if it is empty
+func readDocument(file, format string, stdin io.Reader) (map[string]interface{}, error) {
+	if format == "" {
+		if format = gocfg.FormatOf(file); format == "" {
+			return nil, fmt.Errorf("unknown format of %s, it can be set by -from", file)
+		}
+	}
+
+	var content []byte
+	var err error
+	if file == "-" {
+		content, err = ioutil.ReadAll(stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := gocfg.DecodeDocument(format, content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return doc, nil
+}
+
+// flatten returns values in the file by their paths, values are formatted in json
+func flatten(file, format string, stdin io.Reader) (map[string]string, error) {
+	doc, err := readDocument(file, format, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	err = gocfg.FlattenDocument(doc, func(path string, val interface{}) error {
+		formatted, err := json.Marshal(val)
+		if err != nil {
+			formatted = []byte(fmt.Sprintf("%q", fmt.Sprint(val)))
+		}
+		values[path] = string(formatted)
+		return nil
+	})
+	return values, err
+}
+
+// diff prints lines like "- path = value" for paths only in file1, "+ path = value" for paths only in file2,
+// and "~ path = value1 -> value2" for changed values, errDiffer is returned if there is any line
+func diff(file1, file2, format string, stdin io.Reader, stdout io.Writer) error {
+	values1, err := flatten(file1, format, stdin)
+	if err != nil {
+		return err
+	}
+	values2, err := flatten(file2, format, stdin)
+	if err != nil {
+		return err
+	}
+
+	paths := sortedPaths(values1)
+	for path := range values2 {
+		if _, ok := values1[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	rows := []string{}
+	for _, path := range paths {
+		val1, ok1 := values1[path]
+		val2, ok2 := values2[path]
+		switch {
+		case !ok2:
+			rows = append(rows, fmt.Sprintf("- %s = %s", path, val1))
+		case !ok1:
+			rows = append(rows, fmt.Sprintf("+ %s = %s", path, val2))
+		case val1 != val2:
+			rows = append(rows, fmt.Sprintf("~ %s = %s -> %s", path, val1, val2))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, err = fmt.Fprintln(stdout, strings.Join(rows, "\n")); err != nil {
+		return err
+	}
+	return errDiffer
+}
+
+func sortedPaths(values map[string]string) []string {
+	paths := []string{}
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "gocfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestRun(t *testing.T) {
+	dir, cleanup := writeFiles(t, map[string]string{
+		"app.yaml": "name: app\nservers:\n  - host: a\n    port: 80\n",
+		"app.json": `{"name": "app", "servers": [{"host": "a", "port": 81}, {"host": "b"}], "debug": true}`,
+		"app.env":  "NAME=app\nSERVERS_0_HOST=a\n",
+		"app.conf": "name = \"app\"\n",
+	})
+	defer cleanup()
+	yamlPath, jsonPath := filepath.Join(dir, "app.yaml"), filepath.Join(dir, "app.json")
+
+	testCases := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected string
+		err      error
+	}{
+		{
+			name:     "convert yaml to toml",
+			args:     []string{"convert", "-to", "toml", yamlPath},
+			expected: "name = \"app\"\n\n[[servers]]\n  host = \"a\"\n  port = 80\n",
+		},
+		{
+			name:     "convert stdin to dotenv",
+			args:     []string{"convert", "-from", "json", "-to", "dotenv"},
+			stdin:    `{"name": "my app", "port": 80}`,
+			expected: "NAME=\"my app\"\nPORT=80\n",
+		},
+		{
+			name:     "convert dotenv to json",
+			args:     []string{"convert", "-to", "json", filepath.Join(dir, "app.env")},
+			expected: "{\n  \"NAME\": \"app\",\n  \"SERVERS_0_HOST\": \"a\"\n}",
+		},
+		{
+			name:     "flags after files",
+			args:     []string{"convert", yamlPath, "-to", "json"},
+			expected: "{\n  \"name\": \"app\",\n  \"servers\": [\n    {\n      \"host\": \"a\",\n      \"port\": 80\n    }\n  ]\n}",
+		},
+		{
+			name:     "flags between files",
+			args:     []string{"diff", yamlPath, "-from", "yaml", yamlPath},
+			expected: "",
+		},
+		{
+			name:     "files after --",
+			args:     []string{"flatten", "-from", "json", "--", "-"},
+			stdin:    `{"name": "app"}`,
+			expected: "name = \"app\"\n",
+		},
+		{
+			name:     "flatten",
+			args:     []string{"flatten", jsonPath},
+			expected: "debug = true\nname = \"app\"\nservers[0].host = \"a\"\nservers[0].port = 81\nservers[1].host = \"b\"\n",
+		},
+		{
+			name:     "flatten conf as toml",
+			args:     []string{"flatten", "-from", "toml", filepath.Join(dir, "app.conf")},
+			expected: "name = \"app\"\n",
+		},
+		{
+			name:     "diff",
+			args:     []string{"diff", yamlPath, jsonPath},
+			expected: "+ debug = true\n~ servers[0].port = 80 -> 81\n+ servers[1].host = \"b\"\n",
+			err:      errDiffer,
+		},
+		{
+			name:     "diff same",
+			args:     []string{"diff", yamlPath, yamlPath},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		stdout := &bytes.Buffer{}
+		err := run(tc.args, strings.NewReader(tc.stdin), stdout)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s: error not match: expected: %v, got: %v", tc.name, tc.err, err)
+		}
+		if stdout.String() != tc.expected {
+			t.Fatalf("%s: output not match: expected: %q, got: %q", tc.name, tc.expected, stdout.String())
+		}
+	}
+
+	errCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "unknown format", args: []string{"flatten", filepath.Join(dir, "app.conf")}, expected: "unknown format of"},
+		{name: "missing output format", args: []string{"convert", yamlPath}, expected: "usage:"},
+		{name: "unknown command", args: []string{"merge", yamlPath}, expected: "unknown command merge"},
+		{name: "unknown flag after files", args: []string{"convert", yamlPath, "-into", "json"}, expected: "flag provided but not defined: -into"},
+		{name: "diff stdin twice", args: []string{"diff", "-from", "json", "-", "-"}, expected: "stdin can not be read twice"},
+	}
+	for _, tc := range errCases {
+		err := run(tc.args, strings.NewReader(""), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Fatalf("%s: error not match: expected: %s, got: %v", tc.name, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ContextProvider is a configuration loader interface which can be canceled by the context
+type ContextProvider interface {
+	Load(ctx context.Context, dstCfg interface{}) error
+}
+
+// contextLoader is implemented by providers which support context natively, e.g. HTTPCfg and KVCfg
+type contextLoader interface {
+	LoadContext(ctx context.Context, dstCfg interface{}) error
+}
+
+// ContextCfg adapts a CfgProvider to a ContextProvider
+type ContextCfg struct {
+	pvd CfgProvider
+}
+
+// Contextual inits a ContextCfg for the pvd,
+// providers without context support are loaded into a copy of dstCfg in the background,
+// so dstCfg is not modified once the context is done.
+// Such providers can not be stopped though, if the context is done before they return,
+// their goroutines keep running with the copies until their Load return,
+// e.g. every timed out attempt of Retry(Timeout(Contextual(pvd), ...), ...) leaves one goroutine behind.
+func Contextual(pvd CfgProvider) *ContextCfg {
+	return &ContextCfg{pvd: pvd}
+}
+
+// Load populates the provider according to the definition of the dstCfg
+func (cfg *ContextCfg) Load(ctx context.Context, dstCfg interface{}) error {
+	if loader, ok := cfg.pvd.(contextLoader); ok {
+		return loader.LoadContext(ctx, dstCfg)
+	}
+	if ctx.Done() == nil {
+		// the context is never canceled
+		return cfg.pvd.Load(dstCfg)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dstVal := reflect.ValueOf(dstCfg)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() {
+		return fmt.Errorf("gocfg: dstCfg must be a non-nil pointer")
+	}
+	cloned := cloneValue(dstVal)
+	done := make(chan error, 1)
+	go func() {
+		done <- cfg.pvd.Load(cloned.Interface())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		dstVal.Elem().Set(cloned.Elem())
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RetryCfg retries a ContextProvider with exponential backoff
+type RetryCfg struct {
+	pvd      ContextProvider
+	attempts int
+	backoff  time.Duration
+}
+
+// Retry inits a RetryCfg which loads pvd at most attempts times, and at least once,
+// it waits for backoff before the first retry and the wait is doubled after each retry
+func Retry(pvd ContextProvider, attempts int, backoff time.Duration) *RetryCfg {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &RetryCfg{pvd: pvd, attempts: attempts, backoff: backoff}
+}
+
+// Load populates the provider according to the definition of the dstCfg
+func (cfg *RetryCfg) Load(ctx context.Context, dstCfg interface{}) error {
+	var err error
+	wait := cfg.backoff
+	for i := 0; i < cfg.attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("gocfg: %w after %d attempts: %v", ctx.Err(), i, err)
+			case <-timer.C:
+			}
+			wait *= 2
+		}
+
+		if err = cfg.pvd.Load(ctx, dstCfg); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// TimeoutCfg limits the time of loading a ContextProvider
+type TimeoutCfg struct {
+	pvd     ContextProvider
+	timeout time.Duration
+}
+
+// Timeout inits a TimeoutCfg which cancels loading pvd after the timeout,
+// Load returns once the timeout is reached, but providers wrapped by Contextual keep running in the background
+// until they return, and dstCfg is not modified by them
+func Timeout(pvd ContextProvider, timeout time.Duration) *TimeoutCfg {
+	return &TimeoutCfg{pvd: pvd, timeout: timeout}
+}
+
+// Load populates the provider according to the definition of the dstCfg
+func (cfg *TimeoutCfg) Load(ctx context.Context, dstCfg interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+	return cfg.pvd.Load(ctx, dstCfg)
+}
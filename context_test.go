@@ -0,0 +1,148 @@
+package gocfg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// flakyCfg fails before the nth load
+type flakyCfg struct {
+	loads int
+	n     int
+	delay time.Duration
+}
+
+func (cfg *flakyCfg) Load(dstCfg interface{}) error {
+	cfg.loads++
+	time.Sleep(cfg.delay)
+	if cfg.loads < cfg.n {
+		return errors.New("unavailable")
+	}
+	return JSONStr(`{"name": "loaded"}`).Load(dstCfg)
+}
+
+func TestLoadContext(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+	}
+
+	flaky := &flakyCfg{n: 3}
+	cfg, err := New(&config{}).LoadContext(
+		context.Background(),
+		Retry(Contextual(flaky), 3, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flaky.loads != 3 || cfg.GrabString("Name") != "loaded" {
+		t.Fatalf("key Name not match: expected: loaded, got: %s (%d loads)", cfg.GrabString("Name"), flaky.loads)
+	}
+
+	flaky = &flakyCfg{n: 3}
+	if _, err = New(&config{}).LoadContext(context.Background(), Retry(Contextual(flaky), 2, time.Millisecond)); err == nil {
+		t.Fatal("loading should fail after 2 attempts")
+	}
+
+	// the provider is loaded at least once
+	flaky = &flakyCfg{n: 1}
+	cfg, err = New(&config{}).LoadContext(context.Background(), Retry(Contextual(flaky), 0, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flaky.loads != 1 || cfg.GrabString("Name") != "loaded" {
+		t.Fatalf("key Name not match: expected: loaded, got: %s (%d loads)", cfg.GrabString("Name"), flaky.loads)
+	}
+
+	// retries are stopped once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	flaky = &flakyCfg{n: 10}
+	_, err = New(&config{}).LoadContext(ctx, Retry(Contextual(flaky), 10, time.Second))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error not match: expected: %s, got: %v", context.DeadlineExceeded, err)
+	}
+
+	// the template is not modified by a timed out provider
+	slow := &flakyCfg{n: 1, delay: 200 * time.Millisecond}
+	tplt := &config{Name: "default"}
+	_, err = New(tplt).LoadContext(context.Background(), Timeout(Contextual(slow), 10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error not match: expected: %s, got: %v", context.DeadlineExceeded, err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if tplt.Name != "default" {
+		t.Fatalf("key Name not match: expected: default, got: %s", tplt.Name)
+	}
+}
+
+// blockedCfg loads once it is released, and signals it in loaded
+type blockedCfg struct {
+	release chan struct{}
+	loaded  chan struct{}
+}
+
+func (cfg *blockedCfg) Load(dstCfg interface{}) error {
+	<-cfg.release
+	defer func() { cfg.loaded <- struct{}{} }()
+	return JSONStr(`{"name": "loaded"}`).Load(dstCfg)
+}
+
+func TestLoadContextTimeout(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+	}
+
+	blocked := &blockedCfg{release: make(chan struct{}), loaded: make(chan struct{}, 2)}
+	tplt := &config{Name: "default"}
+	_, err := New(tplt).LoadContext(
+		context.Background(),
+		Retry(Timeout(Contextual(blocked), 10*time.Millisecond), 2, time.Millisecond),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error not match: expected: %s, got: %v", context.DeadlineExceeded, err)
+	}
+
+	// timed out attempts keep loading in the background, and the template is not written once they return
+	close(blocked.release)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-blocked.loaded:
+		case <-time.After(time.Second):
+			t.Fatalf("attempt %d is not returned", i)
+		}
+	}
+	if tplt.Name != "default" {
+		t.Fatalf("key Name not match: expected: default, got: %s", tplt.Name)
+	}
+}
+
+func TestLoadContextHTTP(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+	}
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "remote"}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := New(&config{}).LoadContext(context.Background(), Timeout(Contextual(HTTP(server.URL)), 50*time.Millisecond))
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error not match: expected: %s, got: %v", context.DeadlineExceeded, err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("request should be canceled by the timeout")
+	}
+}
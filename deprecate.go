@@ -0,0 +1,365 @@
+package gocfg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// GocfgValAlias is the option in the cfg tag which lists old names of a field separated by "|",
+// e.g. `cfg:"alias=Port|ListenPort"`, keys named by them are loaded to the field and reported as deprecated
+var GocfgValAlias = "alias="
+
+// GocfgDeprecatedTag is the tag of the message of a deprecated field, e.g. `deprecated:"use Timeout instead"`
+var GocfgDeprecatedTag = "deprecated"
+
+// DeprecatedKey is a key in a config which is an alias or names a deprecated field
+type DeprecatedKey struct {
+	File    string
+	Line    int
+	Column  int
+	Key     string // key in the config, e.g. server.oldPort
+	Path    string // path of the field in the template, e.g. Server.Port
+	Message string
+}
+
+func (k *DeprecatedKey) Error() string {
+	return fmt.Sprintf("%s: key %s is deprecated, %s", k.Source(), k.Key, k.Message)
+}
+
+// Source returns the position of the key like app.yaml:3:5
+func (k *DeprecatedKey) Source() string {
+	pos := k.File
+	if k.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, k.Line)
+	}
+	if k.Column > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, k.Column)
+	}
+	return pos
+}
+
+// DeprecatedKeysError is returned in strict mode if there are deprecated keys in a config
+type DeprecatedKeysError struct {
+	Keys []*DeprecatedKey
+}
+
+func (e *DeprecatedKeysError) Error() string {
+	msgs := []string{}
+	for _, key := range e.Keys {
+		msgs = append(msgs, key.Error())
+	}
+	return fmt.Sprintf("gocfg: deprecated keys: %s", strings.Join(msgs, "; "))
+}
+
+// fieldAliases returns old names of the field in the cfg tag
+func fieldAliases(field reflect.StructField) []string {
+	for _, opt := range cfgTagOptions(field) {
+		if strings.HasPrefix(opt, GocfgValAlias) {
+			return strings.Split(strings.TrimPrefix(opt, GocfgValAlias), "|")
+		}
+	}
+	return []string{}
+}
+
+// cfgTagOptions returns options in the cfg tag of the field
+func cfgTagOptions(field reflect.StructField) []string {
+	opts := []string{}
+	for _, opt := range strings.Split(field.Tag.Get(GocfgTag), ",") {
+		if opt = strings.TrimSpace(opt); opt != "" {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
+
+// deprecation returns the message if the field named by the key is deprecated,
+// path is the path of the field, and keys matching aliases are always deprecated
+func deprecation(field reflect.StructField, key, path string) (string, bool) {
+	if msg := field.Tag.Get(GocfgDeprecatedTag); msg != "" {
+		return msg, true
+	}
+	for _, alias := range fieldAliases(field) {
+		if strings.EqualFold(alias, key) {
+			return fmt.Sprintf("use %s instead", path), true
+		}
+	}
+	return "", false
+}
+
+// hasDeprecations checks if any field in the type t has aliases or is deprecated
+func hasDeprecations(t reflect.Type) bool {
+	var walk func(t reflect.Type, seen map[reflect.Type]bool) bool
+	walk = func(t reflect.Type, seen map[reflect.Type]bool) bool {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return false
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Tag.Get(GocfgDeprecatedTag) != "" || len(fieldAliases(field)) > 0 || walk(field.Type, seen) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(t, map[reflect.Type]bool{})
+}
+
+type deprecationsCtxKey struct{}
+
+// deprecations collects deprecated keys found by providers
+type deprecations struct {
+	mtx  *sync.Mutex
+	keys []*DeprecatedKey
+}
+
+func withDeprecations(ctx context.Context, found *deprecations) context.Context {
+	return context.WithValue(ctx, deprecationsCtxKey{}, found)
+}
+
+// reportDeprecated returns an error for the keys if the ctx is strict, or the keys are collected in the ctx
+func reportDeprecated(ctx context.Context, keys []*DeprecatedKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if isStrict(ctx) {
+		return &DeprecatedKeysError{Keys: keys}
+	}
+	if found, ok := ctx.Value(deprecationsCtxKey{}).(*deprecations); ok {
+		found.mtx.Lock()
+		defer found.mtx.Unlock()
+		found.keys = append(found.keys, keys...)
+	}
+	return nil
+}
+
+// aliasResolver collects deprecated keys and values of aliases in a config
+type aliasResolver struct {
+	name    string
+	walker  *nodeWalker
+	keys    []*DeprecatedKey
+	values  []*docNode // values of aliases, they are decoded to their fields after the content is decoded
+	content []byte
+	renamed bool
+}
+
+// visit records the key of the value n if it is deprecated, and collects values of aliases
+func (ar *aliasResolver) visit(n *docNode) bool {
+	if n.unknown {
+		return false
+	}
+	if n.field == nil {
+		return true
+	}
+	if msg, ok := deprecation(n.field.field, n.keyNode.Value, n.path); ok {
+		ar.keys = append(ar.keys, &DeprecatedKey{
+			File:    ar.name,
+			Line:    n.keyNode.Line,
+			Column:  n.keyNode.Column,
+			Key:     n.docPath,
+			Path:    n.path,
+			Message: msg,
+		})
+	}
+	if n.field.alias {
+		ar.renamed = true
+		if n.shadowed {
+			// the field is named by its name
+			return false
+		}
+		if n.node != nil {
+			ar.values = append(ar.values, n)
+		}
+	}
+	return true
+}
+
+// resolveAliases finds deprecated keys and aliases in the json, yaml, toml or xml content,
+// values of aliases are set by apply after the content is decoded,
+// and aliased elements are renamed in the content for xml
+func resolveAliases(format, name string, content []byte, t reflect.Type, strategy KeyStrategy) *aliasResolver {
+	ar := &aliasResolver{name: name, keys: []*DeprecatedKey{}, values: []*docNode{}, content: content}
+	if !hasDeprecations(t) {
+		return ar
+	}
+
+	ar.walker = &nodeWalker{naming: namingOf(format), strategy: strategy}
+	switch format {
+	case FormatJSON, FormatYAML, FormatTOML:
+		if node, err := documentNode(format, content); err == nil {
+			ar.walker.walk(rootNode(node, t), ar.visit)
+		}
+	case FormatXML:
+		rewritten, err := ar.walker.walkXML(content, t, ar.visit)
+		switch {
+		case err != nil:
+			// invalid content is reported by the decoder
+			ar.keys = []*DeprecatedKey{}
+		case ar.renamed:
+			ar.content = rewritten
+		}
+	}
+	return ar
+}
+
+// apply decodes values of aliases in the content to their fields in dstCfg, which is decoded from the content,
+// values are decoded by the decoder of the format so they are decoded like values of their fields
+func (ar *aliasResolver) apply(format string, content []byte, dstCfg interface{}) error {
+	if len(ar.values) == 0 {
+		return nil
+	}
+
+	var md toml.MetaData
+	tomlDoc := map[string]toml.Primitive{}
+	if format == FormatTOML {
+		var err error
+		if md, err = toml.Decode(string(content), &tomlDoc); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range ar.values {
+		var decodeTo func(ptr interface{}) error
+		switch format {
+		case FormatYAML:
+			decodeTo = n.node.Decode
+		case FormatJSON:
+			raw, ok := rawJSON(content, n.docKeys)
+			if !ok {
+				continue
+			}
+			decodeTo = func(ptr interface{}) error { return json.Unmarshal(raw, ptr) }
+		case FormatTOML:
+			prim, ok := rawTOML(md, tomlDoc, n.docKeys)
+			if !ok {
+				continue
+			}
+			decodeTo = func(ptr interface{}) error { return md.PrimitiveDecode(prim, ptr) }
+		default:
+			continue
+		}
+
+		if err := ar.setAlias(reflect.ValueOf(dstCfg), n.docKeys, decodeTo); err != nil {
+			if found := ar.walker.findTypeError(n); found != nil {
+				found.Format, found.File = format, ar.name
+				return found
+			}
+			return &ParseError{Format: format, File: ar.name, Line: n.node.Line, Column: n.node.Column, Path: n.path, Err: err}
+		}
+	}
+	return nil
+}
+
+// rawJSON returns the value named by the keys in the json content
+func rawJSON(content []byte, keys []string) (json.RawMessage, bool) {
+	raw := json.RawMessage(content)
+	for _, key := range keys {
+		obj := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &obj); err == nil {
+			var ok bool
+			if raw, ok = obj[key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		arr := []json.RawMessage{}
+		idx, err := strconv.Atoi(key)
+		if err != nil || json.Unmarshal(raw, &arr) != nil || idx < 0 || idx >= len(arr) {
+			return nil, false
+		}
+		raw = arr[idx]
+	}
+	return raw, true
+}
+
+// rawTOML returns the value named by the keys in the decoded toml document
+func rawTOML(md toml.MetaData, doc map[string]toml.Primitive, keys []string) (toml.Primitive, bool) {
+	if len(keys) == 0 {
+		return toml.Primitive{}, false
+	}
+	prim, ok := doc[keys[0]]
+	if !ok {
+		return toml.Primitive{}, false
+	}
+	for _, key := range keys[1:] {
+		table := map[string]toml.Primitive{}
+		if err := md.PrimitiveDecode(prim, &table); err == nil {
+			if prim, ok = table[key]; !ok {
+				return toml.Primitive{}, false
+			}
+			continue
+		}
+
+		arr := []toml.Primitive{}
+		idx, err := strconv.Atoi(key)
+		if err != nil || md.PrimitiveDecode(prim, &arr) != nil || idx < 0 || idx >= len(arr) {
+			return toml.Primitive{}, false
+		}
+		prim = arr[idx]
+	}
+	return prim, true
+}
+
+// setAlias decodes a value by decodeTo to the value named by the keys in the config in v,
+// fields are matched like the walker of the resolver, and pointers and maps on the way are created if they are nil
+func (ar *aliasResolver) setAlias(v reflect.Value, keys []string, decodeTo func(ptr interface{}) error) error {
+	v = allocValue(v)
+	if len(keys) == 0 {
+		return decodeTo(v.Addr().Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field := ar.walker.naming.field(v.Type(), keys[0])
+		if field == nil {
+			return nil
+		}
+		for _, inlined := range field.inlinedBy {
+			v = allocValue(v.Field(inlined.Index[0]))
+		}
+		return ar.setAlias(v.Field(field.field.Index[0]), keys[1:], decodeTo)
+	case reflect.Slice, reflect.Array:
+		if idx, err := strconv.Atoi(keys[0]); err == nil && idx >= 0 && idx < v.Len() {
+			return ar.setAlias(v.Index(idx), keys[1:], decodeTo)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf(keys[0]).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := ar.setAlias(elem, keys[1:], decodeTo); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// allocValue returns the value v points to, nil pointers are set to new values
+func allocValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,233 @@
+package gocfg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type deprecatedServer struct {
+	Port    int    `json:"port" yaml:"port" toml:"port" xml:"port" cfg:"alias=listenPort|bindPort"`
+	Timeout int    `json:"timeout" yaml:"timeout" toml:"timeout" xml:"timeout" deprecated:"use TimeoutMs instead"`
+	Name    string `json:"name" yaml:"name" toml:"name" xml:"name"`
+}
+
+type deprecatedConfig struct {
+	Server  *deprecatedServer   `json:"server" yaml:"server" toml:"server" xml:"server"`
+	Servers []*deprecatedServer `json:"servers" yaml:"servers" toml:"servers" xml:"servers"`
+}
+
+func TestDeprecatedKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pvd      func() CfgProvider
+		expected []string
+	}{
+		{
+			name: "yaml",
+			pvd: func() CfgProvider {
+				return YAMLStr("server:\n  listenPort: 80\n  timeout: 1\nservers:\n  - bindPort: 81\n    port: 82\n")
+			},
+			expected: []string{
+				"yaml:2:3: key server.listenPort is deprecated, use Server.Port instead",
+				"yaml:3:3: key server.timeout is deprecated, use TimeoutMs instead",
+				"yaml:5:5: key servers[0].bindPort is deprecated, use Servers[0].Port instead",
+			},
+		},
+		{
+			name: "json",
+			pvd: func() CfgProvider {
+				return JSONStr(`{"server": {"ListenPort": 80, "timeout": 1}, "servers": [{"bindPort": 81, "port": 82}]}`)
+			},
+			expected: []string{
+				"json:1:13: key server.ListenPort is deprecated, use Server.Port instead",
+				"json:1:31: key server.timeout is deprecated, use TimeoutMs instead",
+				"json:1:59: key servers[0].bindPort is deprecated, use Servers[0].Port instead",
+			},
+		},
+		{
+			name: "toml",
+			pvd: func() CfgProvider {
+				return Reader(FormatTOML, strings.NewReader("[server]\nlistenPort = 80\ntimeout = 1\n[[servers]]\nbindPort = 81\nport = 82\n"))
+			},
+			expected: []string{
+				"toml:2:1: key server.listenPort is deprecated, use Server.Port instead",
+				"toml:3:1: key server.timeout is deprecated, use TimeoutMs instead",
+				"toml:5:1: key servers[0].bindPort is deprecated, use Servers[0].Port instead",
+			},
+		},
+		{
+			name: "xml",
+			pvd: func() CfgProvider {
+				return XMLStr("<config>\n<server><listenPort>80</listenPort><timeout>1</timeout></server>\n<servers><bindPort>81</bindPort><port>82</port></servers>\n</config>")
+			},
+			expected: []string{
+				"xml:2:9: key server.listenPort is deprecated, use Server.Port instead",
+				"xml:2:36: key server.timeout is deprecated, use TimeoutMs instead",
+				"xml:3:10: key servers.bindPort is deprecated, use Servers[0].Port instead",
+			},
+		},
+		{
+			name: "dotenv",
+			pvd:  func() CfgProvider { return DotEnvStr("SERVER_LISTENPORT=80\nSERVER_TIMEOUT=1\nSERVERS_0_PORT=82\n") },
+			expected: []string{
+				"dotenv:1: key SERVER_LISTENPORT is deprecated, use Server.Port instead",
+				"dotenv:2: key SERVER_TIMEOUT is deprecated, use TimeoutMs instead",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		logger := newRecordLogger()
+		cfg, err := New(&deprecatedConfig{}, WithLogger(logger)).Load(tc.pvd())
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if cfg.GrabInt("Server.Port") != 80 || cfg.GrabInt("Server.Timeout") != 1 {
+			t.Fatalf("%s: config not match: got: %s", tc.name, cfg.ToString())
+		}
+		if cfg.GrabInt("Servers[0].Port") != 82 {
+			t.Fatalf("%s: key Servers[0].Port not match: expected: 82, got: %d", tc.name, cfg.GrabInt("Servers[0].Port"))
+		}
+
+		warnings := logger.find("WARN", "deprecated key")
+		if len(warnings) != len(tc.expected) {
+			t.Fatalf("%s: warnings not match: expected: %d, got: %d", tc.name, len(tc.expected), len(warnings))
+		}
+		for i, warning := range warnings {
+			got := warning.attrs["source"] + ": key " + warning.attrs["key"] + " is deprecated, " + warning.attrs["message"]
+			if got != tc.expected[i] {
+				t.Fatalf("%s: warning not match: expected: %s, got: %s", tc.name, tc.expected[i], got)
+			}
+		}
+
+		// deprecated keys are errors in strict mode
+		_, err = New(&deprecatedConfig{}, WithStrict()).Load(tc.pvd())
+		deprecatedErr := &DeprecatedKeysError{}
+		if !errors.As(err, &deprecatedErr) || len(deprecatedErr.Keys) != len(tc.expected) {
+			t.Fatalf("%s: error not match: got: %v", tc.name, err)
+		}
+		if deprecatedErr.Keys[0].Error() != tc.expected[0] {
+			t.Fatalf("%s: error not match: expected: %s, got: %s", tc.name, tc.expected[0], deprecatedErr.Keys[0])
+		}
+	}
+}
+
+func TestAliasPaths(t *testing.T) {
+	path, cleanup := writeTempFile(t, "app.properties", "Server.BindPort=80\nServer.Name=app\n")
+	defer cleanup()
+
+	logger := newRecordLogger()
+	cfg, err := New(&deprecatedConfig{}, WithLogger(logger)).Load(Properties(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabInt("Server.Port") != 80 || cfg.GrabString("Server.Name") != "app" {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+	warnings := logger.find("WARN", "deprecated key")
+	if len(warnings) != 1 || warnings[0].attrs["source"] != path+":1" || warnings[0].attrs["path"] != "Server.Port" {
+		t.Fatalf("warnings not match: got: %d", len(warnings))
+	}
+}
+
+func TestAliasValues(t *testing.T) {
+	type config struct {
+		Server deprecatedServer            `json:"server" yaml:"server" toml:"server"`
+		Zones  map[string]deprecatedServer `json:"zones" yaml:"zones" toml:"zones"`
+	}
+
+	contents := map[string]string{
+		FormatJSON: "{\n  \"server\": {\"name\": \"app\"},\n  \"zones\": {\"east\": {\"bindPort\": 80}}\n}",
+		FormatYAML: "server:\n  name: app\nzones:\n  east:\n    bindPort: 80\n",
+		FormatTOML: "[server]\nname = \"app\"\n[zones.east]\nbindPort = 80\n",
+	}
+	for format, content := range contents {
+		cfg, err := New(&config{}).Load(Reader(format, strings.NewReader(content)))
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if cfg.GrabInt("Zones[east].Port") != 80 || cfg.GrabString("Server.Name") != "app" {
+			t.Fatalf("%s: config not match: got: %s", format, cfg.ToString())
+		}
+	}
+
+	// positions of invalid values of aliases are in the original content
+	testCases := []struct {
+		format   string
+		content  string
+		expected string
+	}{
+		{
+			format:   FormatJSON,
+			content:  "{\n  \"server\": {\n    \"name\": \"app\",\n    \"listenPort\": \"abc\"\n  }\n}",
+			expected: "gocfg: json:4:19 Server.Port: expected int, got \"abc\"",
+		},
+		{
+			format:   FormatYAML,
+			content:  "server:\n  name: app\n  listenPort: abc\n",
+			expected: "gocfg: yaml:3:15 Server.Port: expected int, got \"abc\"",
+		},
+		{
+			format:   FormatTOML,
+			content:  "[server]\nname = \"app\"\nlistenPort = \"abc\"\n",
+			expected: "gocfg: toml:3 Server.Port: ",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := New(&config{}).Load(Reader(tc.format, strings.NewReader(tc.content)))
+		parseErr := &ParseError{}
+		if !errors.As(err, &parseErr) || parseErr.Path != "Server.Port" {
+			t.Fatalf("%s: error not match: got: %v", tc.format, err)
+		}
+		if !strings.HasPrefix(err.Error(), tc.expected) {
+			t.Fatalf("%s: error not match: expected: %s, got: %s", tc.format, tc.expected, err)
+		}
+	}
+}
+
+// InlinedServer is exported as it is embedded by pointer
+type InlinedServer struct {
+	Port int `json:"port" yaml:"port" toml:"port" cfg:"alias=bindPort"`
+}
+
+func TestInlinedAliases(t *testing.T) {
+	type config struct {
+		*InlinedServer `yaml:",inline"`
+		Env            string `json:"env" yaml:"env" toml:"env"`
+	}
+
+	contents := map[string]string{
+		FormatJSON: "{\"env\": \"prod\", \"bindPort\": 80}",
+		FormatYAML: "env: prod\nbindPort: 80\n",
+		FormatTOML: "env = \"prod\"\nbindPort = 80\n",
+	}
+	for format, content := range contents {
+		logger := newRecordLogger()
+		cfg, err := New(&config{}, WithLogger(logger)).Load(Reader(format, strings.NewReader(content)))
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if cfg.GrabInt("InlinedServer.Port") != 80 || cfg.GrabString("Env") != "prod" {
+			t.Fatalf("%s: config not match: got: %s", format, cfg.ToString())
+		}
+		warnings := logger.find("WARN", "deprecated key")
+		if len(warnings) != 1 || warnings[0].attrs["path"] != "InlinedServer.Port" {
+			t.Fatalf("%s: warnings not match: got: %v", format, warnings)
+		}
+	}
+
+	// the outermost deprecated field is reported for flat configs
+	type legacyConfig struct {
+		Legacy deprecatedServer `cfg:"alias=old" deprecated:"use Server instead"`
+	}
+	logger := newRecordLogger()
+	cfg, err := New(&legacyConfig{}, WithLogger(logger)).Load(DotEnvStr("OLD_BINDPORT=80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := logger.find("WARN", "deprecated key")
+	if cfg.GrabInt("Legacy.Port") != 80 || len(warnings) != 1 || warnings[0].attrs["path"] != "Legacy" {
+		t.Fatalf("warnings not match: got: %v", warnings)
+	}
+}
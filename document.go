@@ -0,0 +1,183 @@
+package gocfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DecodeDocument decodes json, yaml, toml or dotenv content without a template,
+// values in dotenv content are strings named by their keys
+func DecodeDocument(format string, content []byte) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		return normalizeNumbers(doc).(map[string]interface{}), nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	case FormatTOML:
+		if _, err := toml.Decode(string(content), &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	case FormatDotEnv:
+		lines, err := parseDotEnv(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("gocfg: dotenv:%s", err)
+		}
+		for _, line := range lines {
+			doc[line.key] = line.value
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("gocfg: unknown format %s", format)
+}
+
+// normalizeNumbers replaces json numbers with int64 values, uint64 values for integers larger than int64,
+// or float64 values
+func normalizeNumbers(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = normalizeNumbers(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalizeNumbers(child)
+		}
+	}
+	return val
+}
+
+// EncodeDocument encodes the document in json, yaml, toml or dotenv,
+// the document is flattened in dotenv and keys are named after paths like Dotenv does,
+// null values are rejected in toml and dotenv, and so are keys which can not be named in dotenv
+func EncodeDocument(format string, doc map[string]interface{}) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(doc, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(doc)
+	case FormatTOML:
+		err := FlattenDocument(doc, func(path string, val interface{}) error {
+			if val == nil {
+				return fmt.Errorf("gocfg: toml: null value of %s is not supported", path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		if err = toml.NewEncoder(buf).Encode(doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatDotEnv:
+		rows := []string{}
+		err := FlattenDocument(doc, func(path string, val interface{}) error {
+			if val == nil {
+				return fmt.Errorf("gocfg: dotenv: null value of %s is not supported", path)
+			}
+			key, err := envKey("", path)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, fmt.Sprintf("%s=%s\n", key, quoteEnvValue(fmt.Sprintf("%v", val))))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.Join(rows, "")), nil
+	}
+	return nil, fmt.Errorf("gocfg: unknown format %s", format)
+}
+
+// FlattenDocument calls fn for every value in the document other than maps and slices in the order of paths,
+// paths are like Servers[0].Host, and keys containing dots, brackets or quotes are quoted like Labels["app.name"]
+func FlattenDocument(doc map[string]interface{}, fn func(path string, val interface{}) error) error {
+	return flattenDocument(reflect.ValueOf(doc), "", fn)
+}
+
+func flattenDocument(v reflect.Value, path string, fn func(path string, val interface{}) error) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fn(path, nil)
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		keys := map[string]reflect.Value{}
+		names := []string{}
+		for _, key := range v.MapKeys() {
+			name := fmt.Sprintf("%v", key.Interface())
+			keys[name] = key
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := flattenDocument(v.MapIndex(keys[name]), docPath(path, name), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fn(path, v.Interface())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := flattenDocument(v.Index(i), indexPath(path, i), fn); err != nil {
+				return err
+			}
+		}
+	default:
+		return fn(path, v.Interface())
+	}
+	return nil
+}
+
+// docPath returns the path of a key in a document, it is a field path unless the key has to be quoted
+func docPath(parent, key string) string {
+	if key == "" || strings.ContainsAny(key, `.[]"`) {
+		return keyPath(parent, key)
+	}
+	return fieldPath(parent, key)
+}
+
+// isEnvName checks if the key is a name of environment variables, which has only letters, digits and underscores
+// and does not start with a digit
+func isEnvName(key string) bool {
+	for i, r := range key {
+		isLetter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return key != ""
+}
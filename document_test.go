@@ -0,0 +1,118 @@
+package gocfg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	contents := map[string]string{
+		FormatJSON: `{"name": "app", "labels": {"app.name": "x"}, "servers": [{"host": "a", "port": 80}, {"host": "b", "port": 81.5}]}`,
+		FormatYAML: "name: app\nlabels:\n  app.name: x\nservers:\n  - host: a\n    port: 80\n  - host: b\n    port: 81.5\n",
+		FormatTOML: "name = \"app\"\n[labels]\n\"app.name\" = \"x\"\n[[servers]]\nhost = \"a\"\nport = 80\n[[servers]]\nhost = \"b\"\nport = 81.5\n",
+	}
+	expected := []string{
+		`labels["app.name"] = x`,
+		"name = app",
+		"servers[0].host = a",
+		"servers[0].port = 80",
+		"servers[1].host = b",
+		"servers[1].port = 81.5",
+	}
+
+	flatten := func(doc map[string]interface{}) []string {
+		rows := []string{}
+		err := FlattenDocument(doc, func(path string, val interface{}) error {
+			rows = append(rows, fmt.Sprintf("%s = %v", path, val))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows
+	}
+
+	for format, content := range contents {
+		doc, err := DecodeDocument(format, []byte(content))
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if got := strings.Join(flatten(doc), "\n"); got != strings.Join(expected, "\n") {
+			t.Fatalf("%s: values not match: expected: %s, got: %s", format, expected, got)
+		}
+
+		// values are kept after converting to another format
+		for _, to := range []string{FormatJSON, FormatYAML, FormatTOML} {
+			encoded, err := EncodeDocument(to, doc)
+			if err != nil {
+				t.Fatalf("%s to %s: %s", format, to, err)
+			}
+			converted, err := DecodeDocument(to, encoded)
+			if err != nil {
+				t.Fatalf("%s to %s: %s", format, to, err)
+			}
+			if got := strings.Join(flatten(converted), "\n"); got != strings.Join(expected, "\n") {
+				t.Fatalf("%s to %s: values not match: expected: %s, got: %s", format, to, expected, got)
+			}
+		}
+	}
+
+	doc, err := DecodeDocument(FormatYAML, []byte(contents[FormatYAML]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc["labels"] = map[string]interface{}{"team": "x"}
+	encoded, err := EncodeDocument(FormatDotEnv, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEnv := "LABELS_TEAM=x\nNAME=app\nSERVERS_0_HOST=a\nSERVERS_0_PORT=80\nSERVERS_1_HOST=b\nSERVERS_1_PORT=81.5\n"
+	if string(encoded) != expectedEnv {
+		t.Fatalf("dotenv not match: expected: %s, got: %s", expectedEnv, encoded)
+	}
+	doc, err = DecodeDocument(FormatDotEnv, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc["SERVERS_1_PORT"] != "81.5" || len(doc) != 6 {
+		t.Fatalf("dotenv document not match: got: %v", doc)
+	}
+
+	if _, err = DecodeDocument(FormatXML, []byte("<config></config>")); err == nil {
+		t.Fatal("xml should not be supported")
+	}
+
+	// integers larger than int64 are kept
+	doc, err = DecodeDocument(FormatJSON, []byte(`{"id": 12345678901234567890}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err = EncodeDocument(FormatJSON, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != "{\n  \"id\": 12345678901234567890\n}" {
+		t.Fatalf("json not match: got: %s", encoded)
+	}
+
+	errCases := []struct {
+		format   string
+		content  string
+		expected string
+	}{
+		{format: FormatDotEnv, content: `{"a": {"n": null}}`, expected: "gocfg: dotenv: null value of a.n is not supported"},
+		{format: FormatDotEnv, content: `{"a": {"b.c": 1}}`, expected: `gocfg: dotenv: a["b.c"] can not be named by a key, got A_B.C`},
+		{format: FormatDotEnv, content: `{"a-b": 1}`, expected: "gocfg: dotenv: a-b can not be named by a key, got A-B"},
+		{format: FormatTOML, content: `{"a": [1, null]}`, expected: "gocfg: toml: null value of a[1] is not supported"},
+	}
+	for _, tc := range errCases {
+		doc, err = DecodeDocument(FormatJSON, []byte(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = EncodeDocument(tc.format, doc); err == nil || err.Error() != tc.expected {
+			t.Fatalf("%s: error not match: expected: %s, got: %v", tc.content, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DotEnvCfg is a configuration loader for a local .env file
+type DotEnvCfg struct {
+	path string
+}
+
+// DotEnv inits a DotEnvCfg according to the .env file in the path
+func DotEnv(path string) *DotEnvCfg {
+	return &DotEnvCfg{path: path}
+}
+
+// Source returns the path of the .env file
+func (cfg *DotEnvCfg) Source() string {
+	return cfg.path
+}
+
+// Load populates .env file according to the definition of the dstCfg
+func (cfg *DotEnvCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates .env file like Load with options in the ctx
+func (cfg *DotEnvCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadFile(cfg.path)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, FormatDotEnv, cfg.path, cfgBytes, dstCfg)
+}
+
+// DotEnvStrCfg is a configuration loader for a .env string
+type DotEnvStrCfg struct {
+	content string
+}
+
+// DotEnvStr inits a DotEnvStrCfg according to the content
+func DotEnvStr(content string) *DotEnvStrCfg {
+	return &DotEnvStrCfg{content: content}
+}
+
+// Load populates content according to the definition of the dstCfg
+func (cfg *DotEnvStrCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates content like Load with options in the ctx
+func (cfg *DotEnvStrCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	return decode(ctx, FormatDotEnv, "dotenv", []byte(cfg.content), dstCfg)
+}
+
+// loadDotEnv sets values in the content to the dstCfg,
+// keys are matched with field names in upper case joined by "_", e.g. STRUCTVAL_SLICEVAL_0_INTVAL,
+// keys without matched fields are ignored unless the ctx is strict
+func loadDotEnv(ctx context.Context, name, content string, dstCfg interface{}) error {
+	lines, err := parseDotEnv(content)
+	if err != nil {
+		return fmt.Errorf("gocfg: %s:%s", name, err)
+	}
+
+	v := reflect.ValueOf(dstCfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("gocfg: dstCfg must be a non-nil pointer")
+	}
+	unknownKeys, deprecatedKeys := []*UnknownKey{}, []*DeprecatedKey{}
+	for _, line := range lines {
+		set, err := setByEnvKey(v.Elem(), strings.ToUpper(line.key), line.value, keyStrategyOf(ctx), "")
+		if err != nil {
+			return &ParseError{Format: FormatDotEnv, File: name, Line: line.line, Path: set.path, Err: err}
+		}
+		if set == nil && isStrict(ctx) {
+			candidates := []string{}
+			for _, path := range knownPaths(v.Type(), keyStrategyOf(ctx)) {
+				if key, err := envKey("", path); err == nil {
+					candidates = append(candidates, key)
+				}
+			}
+			unknownKeys = append(unknownKeys, &UnknownKey{
+				File:       name,
+				Line:       line.line,
+				Path:       line.key,
+				Suggestion: suggest(line.key, candidates),
+			})
+		}
+		if set != nil && set.message != "" {
+			deprecatedKeys = append(deprecatedKeys, &DeprecatedKey{
+				File:    name,
+				Line:    line.line,
+				Key:     line.key,
+				Path:    set.deprecatedPath,
+				Message: set.message,
+			})
+		}
+	}
+	if err := unknownKeysErr(unknownKeys); err != nil {
+		return err
+	}
+	return reportDeprecated(ctx, deprecatedKeys)
+}
+
+// parseDotEnv parses lines like `KEY=value`, `export KEY="multiline\nvalue"` or `KEY='raw value' # comment`
+func parseDotEnv(content string) ([]*kvLine, error) {
+	lines := []*kvLine{}
+	lineNum := 1
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	for len(content) > 0 {
+		var row string
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			row, content = content[:i], content[i+1:]
+		} else {
+			row, content = content, ""
+		}
+		startLine := lineNum
+		lineNum++
+
+		row = strings.TrimSpace(row)
+		if row == "" || strings.HasPrefix(row, "#") {
+			continue
+		}
+		if strings.HasPrefix(row, "export ") {
+			row = strings.TrimSpace(strings.TrimPrefix(row, "export "))
+		}
+
+		sep := strings.IndexByte(row, '=')
+		if sep < 0 {
+			return nil, fmt.Errorf("%d: missing '='", startLine)
+		}
+		key := strings.TrimSpace(row[:sep])
+		if !isEnvKey(key) {
+			return nil, fmt.Errorf("%d: invalid key %q", startLine, key)
+		}
+
+		rawVal := strings.TrimLeft(row[sep+1:], " \t")
+		var val string
+		if len(rawVal) > 0 && (rawVal[0] == '"' || rawVal[0] == '\'') {
+			quote := rawVal[0]
+			// the quoted value may continue in the following rows
+			rest := rawVal[1:]
+			for {
+				end := closingQuote(rest, quote)
+				if end >= 0 {
+					val, rawVal = rest[:end], strings.TrimSpace(rest[end+1:])
+					break
+				}
+				if content == "" {
+					return nil, fmt.Errorf("%d: unclosed quote", startLine)
+				}
+				var next string
+				if i := strings.IndexByte(content, '\n'); i >= 0 {
+					next, content = content[:i], content[i+1:]
+				} else {
+					next, content = content, ""
+				}
+				lineNum++
+				rest = rest + "\n" + next
+			}
+			if rawVal != "" && !strings.HasPrefix(rawVal, "#") {
+				return nil, fmt.Errorf("%d: unexpected %q after quoted value", startLine, rawVal)
+			}
+			if quote == '"' {
+				val = unescapeEnvValue(val)
+			}
+		} else {
+			val = rawVal
+			for i := 0; i < len(val); i++ {
+				if val[i] == '#' && (i == 0 || val[i-1] == ' ' || val[i-1] == '\t') {
+					val = val[:i]
+					break
+				}
+			}
+			val = strings.TrimSpace(val)
+		}
+
+		lines = append(lines, &kvLine{key: key, value: val, line: startLine})
+	}
+	return lines, nil
+}
+
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		isLetter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+		if !isLetter && (i == 0 || !(r >= '0' && r <= '9' || r == '.' || r == '-')) {
+			return false
+		}
+	}
+	return true
+}
+
+// closingQuote returns the index of the closing quote, escaped double quotes are skipped
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+		} else if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeEnvValue(val string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\r`, "\r", `\t`, "\t", `\$`, "$")
+	return replacer.Replace(val)
+}
+
+// setByEnvKey sets val to the value in v named by the key, e.g. STRUCTVAL_SLICEVAL_0_INTVAL,
+// fields are named by the strategy in paths, and it returns nil if nothing is named by the key
+func setByEnvKey(v reflect.Value, key, val string, strategy KeyStrategy, path string) (*assignment, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			elem := reflect.New(v.Type().Elem())
+			set, err := setByEnvKey(elem.Elem(), key, val, strategy, path)
+			if set != nil && err == nil {
+				v.Set(elem)
+			}
+			return set, err
+		}
+		return setByEnvKey(v.Elem(), key, val, strategy, path)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+			for _, name := range envNames(field) {
+				if key != name && !strings.HasPrefix(key, name+"_") {
+					continue
+				}
+				childPath := fieldPath(path, strategy.fieldName(field))
+				set, err := setByEnvKey(v.Field(i), strings.TrimPrefix(key[len(name):], "_"), val, strategy, childPath)
+				if set != nil {
+					set.deprecate(field, name, childPath)
+				}
+				if set != nil || err != nil {
+					return set, err
+				}
+			}
+		}
+		return nil, nil
+	case reflect.Slice, reflect.Array:
+		idxStr, rest := key, ""
+		if i := strings.IndexByte(key, '_'); i >= 0 {
+			idxStr, rest = key[:i], key[i+1:]
+		}
+		idx, err := strconv.Atoi(idxStr)
+		if err != nil || idx < 0 || strconv.Itoa(idx) != idxStr {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Array {
+			if idx >= v.Len() {
+				return nil, nil
+			}
+			return setByEnvKey(v.Index(idx), rest, val, strategy, indexPath(path, idx))
+		}
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if idx < v.Len() {
+			elem.Set(v.Index(idx))
+		}
+		set, err := setByEnvKey(elem, rest, val, strategy, indexPath(path, idx))
+		if set != nil && err == nil {
+			for v.Len() <= idx {
+				v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+			}
+			v.Index(idx).Set(elem)
+		}
+		return set, err
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || key == "" {
+			return nil, nil
+		}
+		mapKey, rest := key, ""
+		elemType := v.Type().Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if !isScalar(elemType) {
+			if i := strings.IndexByte(key, '_'); i >= 0 {
+				mapKey, rest = key[:i], key[i+1:]
+			}
+		}
+
+		// env keys are in upper case, so existing keys are matched case-insensitively
+		keyVal := reflect.ValueOf(strings.ToLower(mapKey)).Convert(v.Type().Key())
+		for _, existingKey := range v.MapKeys() {
+			if strings.EqualFold(existingKey.String(), mapKey) {
+				keyVal = existingKey
+				break
+			}
+		}
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(keyVal); existing.IsValid() {
+			elem.Set(existing)
+		}
+		set, err := setByEnvKey(elem, rest, val, strategy, keyPath(path, keyVal.String()))
+		if set != nil && err == nil {
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			v.SetMapIndex(keyVal, elem)
+		}
+		return set, err
+	}
+
+	if key != "" || !isScalar(v.Type()) {
+		return nil, nil
+	}
+	return &assignment{path: path}, setScalar(v, val)
+}
+
+// envNames returns the names of the field in environment variables
+func envNames(field reflect.StructField) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range fieldNames(field) {
+		name = strings.ToUpper(name)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
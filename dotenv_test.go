@@ -0,0 +1,120 @@
+package gocfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	content := `
+# comment
+export NAME=app
+PLAIN = value with spaces # comment
+HASH=a#b
+DOUBLE="line1\nline2 \"quoted\" # not comment"
+SINGLE='raw \n value' # comment
+MULTI="first
+second"
+EMPTY=
+`
+	lines, err := parseDotEnv(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*kvLine{
+		{key: "NAME", value: "app", line: 3},
+		{key: "PLAIN", value: "value with spaces", line: 4},
+		{key: "HASH", value: "a#b", line: 5},
+		{key: "DOUBLE", value: "line1\nline2 \"quoted\" # not comment", line: 6},
+		{key: "SINGLE", value: `raw \n value`, line: 7},
+		{key: "MULTI", value: "first\nsecond", line: 8},
+		{key: "EMPTY", value: "", line: 10},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("lines size not match: expected: %d, got: %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		if *line != *expected[i] {
+			t.Fatalf("line not match: expected: %+v, got: %+v", expected[i], line)
+		}
+	}
+
+	for _, content := range []string{"NOVALUE", `KEY="unclosed`, `KEY="a" b`, "1KEY=a"} {
+		if _, err := parseDotEnv(content); err == nil {
+			t.Fatalf("%s should be invalid", content)
+		}
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	type server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type config struct {
+		Name       string             `json:"name"`
+		Debug      bool               `json:"debug"`
+		HTTPServer *server            `json:"httpServer"`
+		Servers    []*server          `json:"servers"`
+		Labels     map[string]string  `json:"labels"`
+		Zones      map[string]*server `json:"zones"`
+	}
+
+	dir, err := ioutil.TempDir("", "gocfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := `
+NAME="my app"
+DEBUG=true
+HTTP_SERVER_HOST=localhost
+HTTPSERVER_PORT=8080
+SERVERS_1_PORT=81
+LABELS_TEAM=infra
+ZONES_EAST_HOST=east.example.com
+UNKNOWN=1
+`
+	path := filepath.Join(dir, ".env")
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := New(&config{}).Load(DotEnv(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stringVals := map[string]string{
+		"Name":             "my app",
+		"HTTPServer.Host":  "localhost",
+		"Labels[team]":     "infra",
+		"Zones[east].Host": "east.example.com",
+	}
+	for key, val := range stringVals {
+		if cfg.GrabString(key) != val {
+			t.Fatalf("key %s not match: expected: %s, got: %s", key, val, cfg.GrabString(key))
+		}
+	}
+	ints := map[string]int{
+		"HTTPServer.Port": 8080,
+		"Servers[1].Port": 81,
+	}
+	for key, val := range ints {
+		if cfg.GrabInt(key) != val {
+			t.Fatalf("key %s not match: expected: %d, got: %d", key, val, cfg.GrabInt(key))
+		}
+	}
+	if !cfg.GrabBool("Debug") {
+		t.Fatal("key Debug not match: expected: true, got: false")
+	}
+
+	_, err = New(&config{}).Load(DotEnvStr("\nDEBUG=yes"))
+	if err == nil || err.Error() != `gocfg: dotenv:2 Debug: expected bool, got "yes"` {
+		t.Fatalf("error not match: %v", err)
+	}
+}
@@ -0,0 +1,350 @@
+package gocfg
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// encryptedHeader is the first line of files encrypted by Encrypt
+const encryptedHeader = "gocfg:aes256-gcm:v1"
+
+// KeySource returns a 32 bytes key for AES-256-GCM
+type KeySource func() ([]byte, error)
+
+// KeyFromEnv reads the key from the environment variable,
+// the key can be encoded in base64 or hex
+func KeyFromEnv(name string) KeySource {
+	return func() ([]byte, error) {
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("gocfg: key environment %s is not defined", name)
+		}
+		return parseKey(val)
+	}
+}
+
+// KeyFromFile reads the key from the file in the path,
+// the key can be raw bytes or encoded in base64 or hex
+func KeyFromFile(path string) KeySource {
+	return func() ([]byte, error) {
+		keyBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(keyBytes) == 32 {
+			return keyBytes, nil
+		}
+		return parseKey(string(keyBytes))
+	}
+}
+
+func parseKey(val string) ([]byte, error) {
+	val = strings.TrimSpace(val)
+	if key, err := base64.StdEncoding.DecodeString(val); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(val); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("gocfg: key must be 32 bytes encoded in base64 or hex")
+}
+
+// Encrypt encrypts the plaintext with AES-256-GCM, the result can be decrypted by Decrypt
+func Encrypt(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("gocfg: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(encryptedHeader))
+	return []byte(fmt.Sprintf("%s\n%s\n", encryptedHeader, base64.StdEncoding.EncodeToString(sealed))), nil
+}
+
+// Decrypt decrypts the content encrypted by Encrypt
+func Decrypt(content, key []byte) ([]byte, error) {
+	plaintext, err := decrypt(content, key)
+	if err != nil {
+		return nil, fmt.Errorf("gocfg: %w", err)
+	}
+	return plaintext, nil
+}
+
+func decrypt(content, key []byte) ([]byte, error) {
+	parts := bytes.SplitN(bytes.TrimSpace(content), []byte("\n"), 2)
+	if len(parts) != 2 || string(parts[0]) != encryptedHeader {
+		return nil, fmt.Errorf("content is not encrypted by gocfg")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(parts[1])))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted content: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted content: too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(encryptedHeader))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes for AES-256")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptFile encrypts the file in the srcPath and saves it in the dstPath, e.g. app.yaml to app.yaml.enc
+func EncryptFile(srcPath, dstPath string, keySource KeySource) error {
+	key, err := keySource()
+	if err != nil {
+		return err
+	}
+	plaintext, err := ioutil.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	content, err := Encrypt(plaintext, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dstPath, content, 0600)
+}
+
+// EncryptedCfg is a configuration loader which decrypts the content of a provider encrypted by Encrypt
+type EncryptedCfg struct {
+	pvd       CfgProvider
+	keySource KeySource
+}
+
+// Encrypted inits an EncryptedCfg for the pvd, e.g. Encrypted(YAML("app.yaml.enc"), KeyFromEnv("APP_KEY")),
+// the pvd can be any provider decoding content, e.g. a file, a Reader, a FS or a HTTP, but not a KV
+func Encrypted(pvd CfgProvider, keySource KeySource) *EncryptedCfg {
+	return &EncryptedCfg{pvd: pvd, keySource: keySource}
+}
+
+// Source returns the provider of the encrypted content
+func (cfg *EncryptedCfg) Source() string {
+	return describe(cfg.pvd)
+}
+
+// Load decrypts the content of the provider and populates it according to the definition of the dstCfg
+func (cfg *EncryptedCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext decrypts and populates the content like Load with options in the ctx
+func (cfg *EncryptedCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	key, err := cfg.keySource()
+	if err != nil {
+		return &keySourceError{err: err}
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("gocfg: key must be 32 bytes for AES-256")
+	}
+	return Contextual(cfg.pvd).Load(withDecryptionKey(ctx, key), dstCfg)
+}
+
+// keySourceError is an error of reading the key, it is not treated as a missing config by Optional
+type keySourceError struct {
+	err error
+}
+
+func (e *keySourceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *keySourceError) Unwrap() error {
+	return e.err
+}
+
+type decryptionKeyCtxKey struct{}
+
+// withDecryptionKey makes providers decrypt their content with the key before decoding it
+func withDecryptionKey(ctx context.Context, key []byte) context.Context {
+	return context.WithValue(ctx, decryptionKeyCtxKey{}, key)
+}
+
+func decryptionKey(ctx context.Context) ([]byte, bool) {
+	key, ok := ctx.Value(decryptionKeyCtxKey{}).([]byte)
+	return key, ok
+}
+
+// WithDecryptionKey makes Load decrypt inline values like ENC[AES256_GCM,data:...,iv:...] with the key,
+// decrypted values are treated as secrets
+func WithDecryptionKey(keySource KeySource) Option {
+	return func(c *Cfg) {
+		c.keySource = keySource
+	}
+}
+
+// EncryptValue encrypts the value as ENC[AES256_GCM,data:...,iv:...] which can be embedded in plaintext files
+func EncryptValue(val string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", fmt.Errorf("gocfg: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, nonce, []byte(val), nil)
+	return fmt.Sprintf(
+		"ENC[AES256_GCM,data:%s,iv:%s]",
+		base64.StdEncoding.EncodeToString(sealed),
+		base64.StdEncoding.EncodeToString(nonce),
+	), nil
+}
+
+// isEncryptedValue checks if the value is like ENC[...]
+func isEncryptedValue(val string) bool {
+	return strings.HasPrefix(val, "ENC[") && strings.HasSuffix(val, "]")
+}
+
+// decryptValue decrypts the value encrypted by EncryptValue,
+// the authentication tag can be appended to data or given by tag
+func decryptValue(val string, key []byte) (string, error) {
+	fields := strings.Split(strings.TrimSuffix(strings.TrimPrefix(val, "ENC["), "]"), ",")
+	if fields[0] != "AES256_GCM" {
+		return "", fmt.Errorf("unsupported encryption %s", fields[0])
+	}
+
+	parts := map[string][]byte{}
+	for _, field := range fields[1:] {
+		nameVal := strings.SplitN(field, ":", 2)
+		if len(nameVal) != 2 {
+			return "", fmt.Errorf("invalid encrypted value")
+		}
+		if nameVal[0] == "type" {
+			// only strings are supported
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(nameVal[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid %s in encrypted value", nameVal[0])
+		}
+		parts[nameVal[0]] = decoded
+	}
+	if parts["data"] == nil || parts["iv"] == nil {
+		return "", fmt.Errorf("data and iv are required in encrypted value")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(parts["iv"]))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, parts["iv"], append(parts["data"], parts["tag"]...), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %s", err)
+	}
+	return string(plaintext), nil
+}
+
+// decryptValues decrypts inline encrypted values in the template and marks them as secrets
+func (c *Cfg) decryptValues() error {
+	if c.keySource == nil {
+		return nil
+	}
+	key, err := c.keySource()
+	if err != nil {
+		return err
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("gocfg: key must be 32 bytes for AES-256")
+	}
+	return c.decryptFields(reflect.ValueOf(c.template), "", key)
+}
+
+// decryptFields decrypts inline encrypted values in v, v must be settable unless it is a pointer, slice or map
+func (c *Cfg) decryptFields(v reflect.Value, path string, key []byte) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return c.decryptFields(v.Elem(), path, key)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if err := c.decryptFields(elem, path, key); err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.Set(elem)
+		}
+	case reflect.String:
+		if !isEncryptedValue(v.String()) || !v.CanSet() {
+			return nil
+		}
+		plaintext, err := decryptValue(v.String(), key)
+		if err != nil {
+			return fmt.Errorf("gocfg: %s: %s", path, err)
+		}
+		v.SetString(plaintext)
+		c.secrets[path] = true
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := c.decryptFields(v.Index(i), indexPath(path, i), key); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, mapKey := range sortedMapKeys(v) {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(mapKey))
+			if err := c.decryptFields(elem, keyPath(path, mapKey.String()), key); err != nil {
+				return err
+			}
+			v.SetMapIndex(mapKey, elem)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+			if err := c.decryptFields(v.Field(i), fieldPath(path, c.fieldName(field)), key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package gocfg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncrypted(t *testing.T) {
+	type config struct {
+		User     string `yaml:"user"`
+		Password string `yaml:"password"`
+	}
+
+	key := bytes.Repeat([]byte{7}, 32)
+	keyEnv := "GOCFG_TEST_KEY"
+	if err := os.Setenv(keyEnv, base64.StdEncoding.EncodeToString(key)); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(keyEnv)
+
+	srcPath, cleanup := writeTempFile(t, "app.yaml", "user: admin\npassword: secret\n")
+	defer cleanup()
+	dstPath := srcPath + ".enc"
+	if err := EncryptFile(srcPath, dstPath, KeyFromEnv(keyEnv)); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Fatal("encrypted file should not contain plaintext")
+	}
+
+	cfg, err := New(&config{}).Load(Encrypted(YAML(dstPath), KeyFromEnv(keyEnv)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("User") != "admin" || cfg.GrabString("Password") != "secret" {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+
+	keyPath := filepath.Join(filepath.Dir(srcPath), "key")
+	if err = ioutil.WriteFile(keyPath, key, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = New(&config{}).Load(Encrypted(YAML(dstPath), KeyFromFile(keyPath))); err != nil {
+		t.Fatal(err)
+	}
+
+	// any provider decoding content can be decrypted
+	dir := filepath.Dir(srcPath)
+	pvds := []CfgProvider{
+		FS(os.DirFS(dir), filepath.Base(dstPath)),
+		Reader(FormatYAML, bytes.NewReader(content)),
+	}
+	for _, pvd := range pvds {
+		cfg, err = New(&config{}).Load(Encrypted(pvd, KeyFromEnv(keyEnv)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GrabString("Password") != "secret" {
+			t.Fatalf("config not match: got: %s", cfg.ToString())
+		}
+	}
+
+	wrongKey := func() ([]byte, error) { return bytes.Repeat([]byte{8}, 32), nil }
+	_, err = New(&config{}).Load(Encrypted(YAML(dstPath), wrongKey))
+	if err == nil || !strings.HasPrefix(err.Error(), fmt.Sprintf("gocfg: %s: failed to decrypt", dstPath)) {
+		t.Fatalf("decrypting with a wrong key should fail: %v", err)
+	}
+	_, err = New(&config{}).Load(Encrypted(YAML(srcPath), KeyFromEnv(keyEnv)))
+	if err == nil || err.Error() != fmt.Sprintf("gocfg: %s: content is not encrypted by gocfg", srcPath) {
+		t.Fatalf("decrypting a plaintext file should fail: %v", err)
+	}
+	_, err = New(&config{}).Load(Encrypted(YAML(filepath.Join(dir, "missing.yaml.enc")), KeyFromEnv(keyEnv)))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing file should be reported: %v", err)
+	}
+	if _, err = New(&config{}).Load(Encrypted(YAML(dstPath), KeyFromEnv("GOCFG_TEST_MISSING_KEY"))); err == nil {
+		t.Fatal("missing key should be reported")
+	}
+}
+
+func TestInlineEncryptedValues(t *testing.T) {
+	type database struct {
+		User     string `yaml:"user"`
+		Password string `yaml:"password"`
+	}
+	type config struct {
+		Database *database         `yaml:"database"`
+		Tokens   map[string]string `yaml:"tokens"`
+	}
+
+	key := bytes.Repeat([]byte{7}, 32)
+	keySource := func() ([]byte, error) { return key, nil }
+	password, err := EncryptValue("secret", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := EncryptValue("token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "database:\n  user: admin\n  password: " + password + "\ntokens:\n  ci: " + token + "\n"
+	cfg, err := New(&config{}, WithDecryptionKey(keySource)).Load(YAMLStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Database.Password") != "secret" || cfg.GrabString("Tokens[ci]") != "token" {
+		t.Fatalf("values should be decrypted: %s", cfg.GrabString("Database.Password"))
+	}
+	if cfg.Template().(*config).Database.Password != "secret" {
+		t.Fatal("template should be decrypted")
+	}
+
+	out := cfg.ToString()
+	if strings.Contains(out, "secret") || strings.Contains(out, "token") || !strings.Contains(out, "admin") {
+		t.Fatalf("decrypted values should be masked:\n%s", out)
+	}
+	jsonOut, err := cfg.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jsonOut != `{"Database":{"User":"admin","Password":"******"},"Tokens":{"ci":"******"}}` {
+		t.Fatalf("json not match: got: %s", jsonOut)
+	}
+
+	// values are kept as is without the key
+	cfg, err = New(&config{}).Load(YAMLStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Database.Password") != password {
+		t.Fatalf("value should not be decrypted: %s", cfg.GrabString("Database.Password"))
+	}
+
+	wrongKey := func() ([]byte, error) { return bytes.Repeat([]byte{8}, 32), nil }
+	_, err = New(&config{}, WithDecryptionKey(wrongKey)).Load(YAMLStr(input))
+	if err == nil || !strings.HasPrefix(err.Error(), "gocfg: Database.Password: failed to decrypt") {
+		t.Fatalf("error not match: %v", err)
+	}
+}
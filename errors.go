@@ -0,0 +1,168 @@
+package gocfg
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ParseError is an error in a config with its position
+type ParseError struct {
+	Format string // format of the config, e.g. yaml, or kv for values in a KV store
+	File   string
+	Line   int
+	Column int
+	Path   string // path of the value in the template named by the key strategy like paths in Get, e.g. StructVal.IntVal
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	pos := e.File
+	if e.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, e.Line)
+	}
+	if e.Column > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, e.Column)
+	}
+	if e.Path != "" {
+		pos = fmt.Sprintf("%s %s", pos, e.Path)
+	}
+	return fmt.Sprintf("gocfg: %s: %s", pos, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	yamlLineRe    = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	yamlTypeErrRe = regexp.MustCompile(`^line (\d+): (.*)$`)
+	tomlTypeErrRe = regexp.MustCompile(`^toml: line (\d+) \(last key "(.*)"\): (.*)$`)
+)
+
+// parseError wraps the err returned by the decoder of the format in a ParseError with its position in the content,
+// fields are named by the strategy in paths
+func parseError(format, name string, content []byte, dstCfg interface{}, err error, strategy KeyStrategy) error {
+	pe := &ParseError{Format: format, File: name, Err: err}
+	walker := &nodeWalker{naming: namingOf(format), strategy: strategy}
+	var root *docNode
+	if format != FormatXML {
+		if node, nodeErr := documentNode(format, content); nodeErr == nil {
+			root = rootNode(node, reflect.TypeOf(dstCfg))
+		}
+	}
+
+	switch format {
+	case FormatJSON, FormatYAML:
+		if root != nil {
+			if found := walker.findTypeError(root); found != nil {
+				found.Format, found.File = format, name
+				return found
+			}
+		}
+
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		var yamlTypeErr *yaml.TypeError
+		switch {
+		case errors.As(err, &syntaxErr) && syntaxErr.Offset > 0:
+			// the error occurred at the last read byte
+			pe.Line, pe.Column = position(content, syntaxErr.Offset-1)
+		case errors.As(err, &typeErr):
+			pe.Line, pe.Column = position(content, typeErr.Offset)
+			pe.Path = walker.pathAt(root, typeErr.Field, pe.Line, pe.Column, jsonKey)
+		case errors.As(err, &yamlTypeErr) && len(yamlTypeErr.Errors) > 0:
+			if matched := yamlTypeErrRe.FindStringSubmatch(yamlTypeErr.Errors[0]); matched != nil {
+				pe.Line, _ = strconv.Atoi(matched[1])
+				pe.Path, pe.Err = walker.pathAt(root, "", pe.Line, 0, nil), errors.New(matched[2])
+			}
+		default:
+			if matched := yamlLineRe.FindStringSubmatch(err.Error()); matched != nil {
+				pe.Line, _ = strconv.Atoi(matched[1])
+				pe.Err = errors.New(matched[2])
+			}
+		}
+	case FormatTOML:
+		var tomlErr toml.ParseError
+		if errors.As(err, &tomlErr) {
+			pe.Line = tomlErr.Position.Line
+			pe.Path = walker.pathAt(root, tomlErr.LastKey, pe.Line, 0, tomlKey)
+			if start := int64(tomlErr.Position.Start); start > 0 && start <= int64(len(content)) {
+				_, pe.Column = position(content, start)
+			}
+			if tomlErr.Message != "" {
+				pe.Err = errors.New(tomlErr.Message)
+			}
+		} else if matched := tomlTypeErrRe.FindStringSubmatch(err.Error()); matched != nil {
+			pe.Line, _ = strconv.Atoi(matched[1])
+			lastKey, unquoteErr := strconv.Unquote(`"` + matched[2] + `"`)
+			if unquoteErr != nil {
+				lastKey = matched[2]
+			}
+			pe.Path, pe.Err = walker.pathAt(root, lastKey, pe.Line, 0, tomlKey), errors.New(matched[3])
+		}
+	case FormatXML:
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			pe.Line, pe.Err = syntaxErr.Line, errors.New(syntaxErr.Msg)
+		}
+	}
+	return pe
+}
+
+// pathAt returns the path in the template of the value at the line in the root, which is named by the key of the decoder,
+// the last value before the column is chosen if there are several, and keyOf formats keys in the content like the decoder.
+// The key is returned as it is if no value is found.
+func (w *nodeWalker) pathAt(root *docNode, key string, line, column int, keyOf func(string) string) string {
+	if root == nil || line <= 0 {
+		return key
+	}
+
+	path := key
+	w.walk(root, func(n *docNode) bool {
+		pos := n.node
+		if n.keyNode != nil {
+			pos = n.keyNode
+		}
+		if pos.Line != line || column > 0 && pos.Column > column {
+			return true
+		}
+		if keyOf != nil && key != "" && n.keyNode != nil {
+			// keys of decoders are dotted keys from the root without indexes
+			last := keyOf(n.keyNode.Value)
+			if key != last && !strings.HasSuffix(key, "."+last) {
+				return true
+			}
+		}
+		path = n.path
+		return true
+	})
+	return path
+}
+
+// jsonKey formats the key like the json decoder in paths of errors
+func jsonKey(key string) string {
+	return key
+}
+
+// tomlKey formats the key like the toml decoder in paths of errors
+func tomlKey(key string) string {
+	return toml.Key{key}.String()
+}
+
+func typeMismatch(node *yaml.Node, t reflect.Type, path, got string) *ParseError {
+	return &ParseError{
+		Line:   node.Line,
+		Column: node.Column,
+		Path:   path,
+		Err:    fmt.Errorf("expected %s, got %s", t.Kind(), got),
+	}
+}
@@ -0,0 +1,130 @@
+package gocfg
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	type item struct {
+		IntVal int `json:"intVal" yaml:"intVal" toml:"intVal"`
+	}
+	type config struct {
+		StructVal item            `json:"structVal" yaml:"structVal" toml:"structVal"`
+		SliceVal  []*item         `json:"sliceVal" yaml:"sliceVal" toml:"sliceVal"`
+		MapVal    map[string]bool `json:"mapVal" yaml:"mapVal" toml:"mapVal"`
+	}
+
+	testCases := []struct {
+		name     string
+		pvd      CfgProvider
+		expected string
+	}{
+		{
+			name:     "yaml type",
+			pvd:      YAMLStr("structVal:\n  intVal: 1\nsliceVal:\n  - intVal: 2\n  - intVal: abc\n"),
+			expected: `gocfg: yaml:5:13 SliceVal[1].IntVal: expected int, got "abc"`,
+		},
+		{
+			name:     "yaml structure",
+			pvd:      YAMLStr("structVal: 1\n"),
+			expected: `gocfg: yaml:1:12 StructVal: expected struct, got "1"`,
+		},
+		{
+			name:     "yaml map",
+			pvd:      YAMLStr("mapVal:\n  a.b: maybe\n"),
+			expected: `gocfg: yaml:2:8 MapVal["a.b"]: expected bool, got "maybe"`,
+		},
+		{
+			name:     "yaml syntax",
+			pvd:      YAMLStr("structVal:\n  intVal: 1\n bad\n"),
+			expected: "gocfg: yaml:2: did not find expected key",
+		},
+		{
+			name:     "json type",
+			pvd:      JSONStr("{\n  \"structVal\": {\"intVal\": \"1\"}\n}"),
+			expected: `gocfg: json:2:27 StructVal.IntVal: expected int, got "1"`,
+		},
+		{
+			name:     "json syntax",
+			pvd:      JSONStr("{\n  \"structVal\": {\"intVal\": 1,}\n}"),
+			expected: "gocfg: json:2:29: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:     "xml syntax",
+			pvd:      XMLStr("<config>\n<structVal>\n</config>"),
+			expected: "gocfg: xml:3: element <structVal> closed by </config>",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := New(&config{}).Load(tc.pvd)
+		parseErr := &ParseError{}
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%s: error not match: got: %v", tc.name, err)
+		}
+		if err.Error() != tc.expected {
+			t.Fatalf("%s: error not match: expected: %s, got: %s", tc.name, tc.expected, err)
+		}
+	}
+
+	// paths are paths in the template for all formats
+	pathCases := []struct {
+		name string
+		pvd  CfgProvider
+		line int
+		path string
+	}{
+		{
+			name: "toml",
+			pvd:  Reader(FormatTOML, strings.NewReader("[structVal]\nintVal = 'abc'\n")),
+			line: 2,
+			path: "StructVal.IntVal",
+		},
+		{
+			name: "toml tables",
+			pvd:  Reader(FormatTOML, strings.NewReader("[[sliceVal]]\nintVal = 1\n[[sliceVal]]\nintVal = 'abc'\n")),
+			line: 4,
+			path: "SliceVal[1].IntVal",
+		},
+		{
+			name: "toml map",
+			pvd:  Reader(FormatTOML, strings.NewReader("[mapVal]\n\"a.b\" = 'maybe'\n")),
+			line: 2,
+			path: `MapVal["a.b"]`,
+		},
+		{
+			name: "properties",
+			pvd:  Reader(FormatProperties, strings.NewReader("struct_val.int_val=abc\n")),
+			line: 1,
+			path: "StructVal.IntVal",
+		},
+		{
+			name: "ini",
+			pvd:  Reader(FormatINI, strings.NewReader("[sliceVal.0]\nintval = abc\n")),
+			line: 2,
+			path: "SliceVal[0].IntVal",
+		},
+		{
+			name: "dotenv",
+			pvd:  DotEnvStr("SLICEVAL_0_INTVAL=abc\n"),
+			line: 1,
+			path: "SliceVal[0].IntVal",
+		},
+	}
+	for _, tc := range pathCases {
+		_, err := New(&config{}).Load(tc.pvd)
+		parseErr := &ParseError{}
+		if !errors.As(err, &parseErr) || parseErr.Line != tc.line || parseErr.Path != tc.path {
+			t.Fatalf("%s: error not match: got: %v", tc.name, err)
+		}
+	}
+
+	// unknown formats are not decoded as xml
+	err := decodeDocument(context.Background(), "hcl", "app.hcl", []byte("<config></config>"), &config{})
+	if err == nil || err.Error() != "gocfg: unknown format hcl" {
+		t.Fatalf("error not match: got: %v", err)
+	}
+}
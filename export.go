@@ -0,0 +1,124 @@
+package gocfg
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// YAML returns all configs as a YAML in a string
+func (c *Cfg) YAML() (string, error) {
+	tpltBytes, err := yaml.Marshal(c.masked(c.template, ""))
+	if err != nil {
+		return "", err
+	}
+	return string(tpltBytes), nil
+}
+
+// TOML returns all configs as a TOML in a string
+func (c *Cfg) TOML() (string, error) {
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(c.masked(c.template, "")); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Dotenv returns all configs as KEY=value lines, keys are named after their paths,
+// e.g. StructVal.SliceVal[0].IntVal with prefix APP is named APP_STRUCTVAL_SLICEVAL_0_INTVAL,
+// and an error is returned if a path can not be named by a key, e.g. a map key with dots
+func (c *Cfg) Dotenv(prefix string) (string, error) {
+	rows := []string{}
+	err := c.flatten(reflect.ValueOf(c.masked(c.template, "")), "", func(path string, v reflect.Value) error {
+		key, err := envKey(prefix, path)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, fmt.Sprintf("%s=%s", key, quoteEnvValue(fmt.Sprintf("%v", v.Interface()))))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(rows, "\n"), nil
+}
+
+// flatten calls fn for every bool, number and string value in v with its path
+func (c *Cfg) flatten(v reflect.Value, path string, fn func(path string, v reflect.Value) error) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fn(path, v)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := c.flatten(v.Index(i), indexPath(path, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range sortedMapKeys(v) {
+			if err := c.flatten(v.MapIndex(key), keyPath(path, key.String()), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+			if err := c.flatten(v.Field(i), fieldPath(path, c.fieldName(field)), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// envKey names the path as an environment variable,
+// segments of the path are upper cased and joined by "_",
+// and an error is returned if the name is not a name of environment variables, e.g. for map keys with dots
+func envKey(prefix, path string) (string, error) {
+	segments, err := SplitPath(path)
+	if err != nil {
+		return "", err
+	}
+	if prefix != "" {
+		segments = append([]string{strings.TrimSuffix(prefix, "_")}, segments...)
+	}
+	key := strings.ToUpper(strings.Join(segments, "_"))
+	if !isEnvName(key) {
+		return "", fmt.Errorf("gocfg: dotenv: %s can not be named by a key, got %s", path, key)
+	}
+	return key, nil
+}
+
+// quoteEnvValue quotes the value if it contains characters other than letters, digits and [_-./:@,+]
+func quoteEnvValue(val string) string {
+	plain := strings.IndexFunc(val, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-./:@,+", r))
+	}) < 0
+	if plain {
+		return val
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+	return fmt.Sprintf(`"%s"`, replacer.Replace(val))
+}
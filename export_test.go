@@ -0,0 +1,88 @@
+package gocfg
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	type server struct {
+		Host string `json:"host" yaml:"host" toml:"host"`
+		Port int    `json:"port" yaml:"port" toml:"port"`
+	}
+	type config struct {
+		Name    string            `json:"name" yaml:"name" toml:"name"`
+		Debug   bool              `json:"debug" yaml:"debug" toml:"debug"`
+		Ratio   float64           `json:"ratio" yaml:"ratio" toml:"ratio"`
+		Labels  map[string]string `json:"labels" yaml:"labels" toml:"labels"`
+		Servers []*server         `json:"servers" yaml:"servers" toml:"servers"`
+	}
+
+	input := `{
+		"name": "my app",
+		"debug": true,
+		"ratio": 0.5,
+		"labels": {"team": "infra"},
+		"servers": [{"host": "localhost", "port": 80}]
+	}`
+	cfg, err := New(&config{}).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		out, err := cfg.YAML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "name: my app\ndebug: true\nratio: 0.5\nlabels:\n    team: infra\nservers:\n    - host: localhost\n      port: 80\n"
+		if out != expected {
+			t.Fatalf("yaml not match: expected:\n%s\ngot:\n%s", expected, out)
+		}
+
+		reloaded, err := New(&config{}).Load(YAMLStr(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reloaded.ToString() != cfg.ToString() {
+			t.Fatalf("reloaded yaml not match: expected:\n%s\ngot:\n%s", cfg.ToString(), reloaded.ToString())
+		}
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		out, err := cfg.TOML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "name = \"my app\"\ndebug = true\nratio = 0.5\n\n[labels]\n  team = \"infra\"\n\n[[servers]]\n  host = \"localhost\"\n  port = 80\n"
+		if out != expected {
+			t.Fatalf("toml not match: expected:\n%s\ngot:\n%s", expected, out)
+		}
+	})
+
+	t.Run("dotenv", func(t *testing.T) {
+		out, err := cfg.Dotenv("APP")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "APP_NAME=\"my app\"\nAPP_DEBUG=true\nAPP_RATIO=0.5\nAPP_LABELS_TEAM=infra\nAPP_SERVERS_0_HOST=localhost\nAPP_SERVERS_0_PORT=80"
+		if out != expected {
+			t.Fatalf("dotenv not match: expected:\n%s\ngot:\n%s", expected, out)
+		}
+
+		// keys which are not names of environment variables are rejected
+		for label, expected := range map[string]string{
+			"a.b": `gocfg: dotenv: Labels["a.b"] can not be named by a key, got APP_LABELS_A.B`,
+			"a b": "gocfg: dotenv: Labels[a b] can not be named by a key, got APP_LABELS_A B",
+			"a-b": "gocfg: dotenv: Labels[a-b] can not be named by a key, got APP_LABELS_A-B",
+		} {
+			labeled, err := New(&config{}).Load(JSONStr(fmt.Sprintf(`{"labels": {%q: "infra"}}`, label)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = labeled.Dotenv("APP"); err == nil || err.Error() != expected {
+				t.Fatalf("error of %s not match: expected: %s, got: %v", label, expected, err)
+			}
+		}
+	})
+}
@@ -1,5 +1,8 @@
 module github.com/ihexxa/gocfg
 
-go 1.13
+go 1.16
 
-require gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
+require (
+	github.com/BurntSushi/toml v1.3.2
+	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
+)
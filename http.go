@@ -0,0 +1,206 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPCfg is a configuration loader for a remote config endpoint
+type HTTPCfg struct {
+	url     string
+	format  string
+	client  *http.Client
+	headers http.Header
+
+	mtx        *sync.Mutex
+	body       []byte
+	bodyFormat string
+	etag       string
+	expiresAt  time.Time
+	now        func() time.Time
+}
+
+// HTTP inits a HTTPCfg which fetches config from the url,
+// the ETag and Cache-Control of responses are honored when it is loaded again
+func HTTP(url string) *HTTPCfg {
+	return &HTTPCfg{
+		url:     url,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		headers: http.Header{},
+		mtx:     &sync.Mutex{},
+		now:     time.Now,
+	}
+}
+
+// Source returns the url of the config
+func (cfg *HTTPCfg) Source() string {
+	return cfg.url
+}
+
+// WithFormat sets the format of the content, otherwise it is detected from the Content-Type or the url
+func (cfg *HTTPCfg) WithFormat(format string) *HTTPCfg {
+	cfg.format = format
+	return cfg
+}
+
+// WithTimeout sets the timeout of each request, it is 10 seconds by default,
+// the client is copied so that a client set by WithClient is not changed
+func (cfg *HTTPCfg) WithTimeout(timeout time.Duration) *HTTPCfg {
+	client := *cfg.client
+	client.Timeout = timeout
+	cfg.client = &client
+	return cfg
+}
+
+// WithClient replaces the http.Client used in requests
+func (cfg *HTTPCfg) WithClient(client *http.Client) *HTTPCfg {
+	cfg.client = client
+	return cfg
+}
+
+// WithHeader sets a header in each request
+func (cfg *HTTPCfg) WithHeader(key, val string) *HTTPCfg {
+	cfg.headers.Set(key, val)
+	return cfg
+}
+
+// WithBearerToken sets a bearer token in the Authorization header
+func (cfg *HTTPCfg) WithBearerToken(token string) *HTTPCfg {
+	return cfg.WithHeader("Authorization", fmt.Sprintf("Bearer %s", token))
+}
+
+// WithBasicAuth sets the user and password in the Authorization header
+func (cfg *HTTPCfg) WithBasicAuth(user, password string) *HTTPCfg {
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth(user, password)
+	return cfg.WithHeader("Authorization", req.Header.Get("Authorization"))
+}
+
+// Load populates the fetched config according to the definition of the dstCfg
+func (cfg *HTTPCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates the fetched config like Load, and the request is canceled once the ctx is done
+func (cfg *HTTPCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	body, format, _, err := cfg.fetch(ctx, false)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, format, cfg.url, body, dstCfg)
+}
+
+// Poll checks if the remote config is changed by a conditional request, the Cache-Control is bypassed.
+// Load it again to apply the change.
+func (cfg *HTTPCfg) Poll() (bool, error) {
+	_, _, changed, err := cfg.fetch(context.Background(), true)
+	return changed, err
+}
+
+// fetch returns the cached body if it is fresh or not modified, otherwise it returns the fetched body,
+// the third returned value is true if the body is different from the cached one
+func (cfg *HTTPCfg) fetch(ctx context.Context, revalidate bool) ([]byte, string, bool, error) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+
+	if !revalidate && cfg.body != nil && cfg.now().Before(cfg.expiresAt) {
+		return cfg.body, cfg.bodyFormat, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	for key, vals := range cfg.headers {
+		req.Header[key] = vals
+	}
+	if cfg.body != nil && cfg.etag != "" {
+		req.Header.Set("If-None-Match", cfg.etag)
+	}
+
+	resp, err := cfg.client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cfg.body == nil {
+			return nil, "", false, fmt.Errorf("gocfg: failed to fetch %s: %s without cache", cfg.url, resp.Status)
+		}
+		cfg.expiresAt = cfg.expiry(resp.Header.Get("Cache-Control"))
+		return cfg.body, cfg.bodyFormat, false, nil
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", false, err
+		}
+		format := cfg.detectFormat(resp.Header.Get("Content-Type"))
+		changed := cfg.body == nil || string(body) != string(cfg.body)
+
+		cacheControl := resp.Header.Get("Cache-Control")
+		if strings.Contains(strings.ToLower(cacheControl), "no-store") {
+			cfg.body, cfg.bodyFormat, cfg.etag, cfg.expiresAt = nil, "", "", time.Time{}
+			return body, format, true, nil
+		}
+		cfg.body = body
+		cfg.bodyFormat = format
+		cfg.etag = resp.Header.Get("ETag")
+		cfg.expiresAt = cfg.expiry(cacheControl)
+		return body, format, changed, nil
+	case http.StatusNotFound:
+		return nil, "", false, fmt.Errorf("%w: %s", ErrNotFound, cfg.url)
+	}
+	return nil, "", false, fmt.Errorf("gocfg: failed to fetch %s: %s", cfg.url, resp.Status)
+}
+
+// expiry returns when the cached body expires according to the max-age of the Cache-Control
+func (cfg *HTTPCfg) expiry(cacheControl string) time.Time {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		if directive == "no-cache" {
+			return time.Time{}
+		}
+		if strings.HasPrefix(directive, "max-age=") {
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err == nil && seconds > 0 {
+				return cfg.now().Add(time.Duration(seconds) * time.Second)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// detectFormat returns the format set by WithFormat, or the format of the Content-Type or the url
+func (cfg *HTTPCfg) detectFormat(contentType string) string {
+	if cfg.format != "" {
+		return cfg.format
+	}
+
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		return FormatJSON
+	case strings.Contains(contentType, "yaml"):
+		return FormatYAML
+	case strings.Contains(contentType, "toml"):
+		return FormatTOML
+	case strings.Contains(contentType, "xml"):
+		return FormatXML
+	}
+
+	if u, err := url.Parse(cfg.url); err == nil {
+		if format := FormatOf(u.Path); format != "" {
+			return format
+		}
+	}
+	return FormatJSON
+}
@@ -0,0 +1,106 @@
+package gocfg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTP(t *testing.T) {
+	type config struct {
+		Name   string `json:"name" yaml:"name"`
+		IntVal int    `json:"intVal" yaml:"intVal"`
+	}
+
+	mtx := &sync.Mutex{}
+	content, etag := "name: v1\nintVal: 1\n", `"v1"`
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		requests++
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	pvd := HTTP(server.URL).WithBearerToken("secret").WithTimeout(time.Second)
+	pvd.now = func() time.Time { return now }
+
+	cfg, err := New(&config{}).Load(pvd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "v1" || cfg.GrabInt("IntVal") != 1 {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+
+	// the cached content is fresh
+	if _, err = New(&config{}).Load(pvd); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Fatalf("requests not match: expected: 1, got: %d", requests)
+	}
+
+	// the cached content expires but it is not modified
+	now = now.Add(2 * time.Minute)
+	cfg, err = New(&config{}).Load(pvd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || notModified != 1 || cfg.GrabString("Name") != "v1" {
+		t.Fatalf("conditional request not match: requests: %d, not modified: %d, config: %s", requests, notModified, cfg.ToString())
+	}
+
+	changed, err := pvd.Poll()
+	if err != nil {
+		t.Fatal(err)
+	} else if changed {
+		t.Fatal("config should not be changed")
+	}
+
+	mtx.Lock()
+	content, etag = "name: v2\nintVal: 2\n", `"v2"`
+	mtx.Unlock()
+	changed, err = pvd.Poll()
+	if err != nil {
+		t.Fatal(err)
+	} else if !changed {
+		t.Fatal("config should be changed")
+	}
+	cfg, err = New(&config{}).Load(pvd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "v2" || cfg.GrabInt("IntVal") != 2 {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+
+	_, err = New(&config{}).Load(HTTP(server.URL).WithBasicAuth("user", "wrong"))
+	if err == nil {
+		t.Fatal("unauthorized request should be reported")
+	}
+
+	// the timeout does not change the client shared by the caller
+	shared := &http.Client{}
+	pvd = HTTP(server.URL).WithClient(shared).WithTimeout(time.Second)
+	if shared.Timeout != 0 || pvd.client.Timeout != time.Second {
+		t.Fatalf("timeout not match: shared: %s, provider: %s", shared.Timeout, pvd.client.Timeout)
+	}
+}
@@ -0,0 +1,100 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// INICfg is a configuration loader for a local ini file
+type INICfg struct {
+	path string
+}
+
+// INI inits an INICfg according to the ini file in the path,
+// sections and keys are mapped to paths, e.g. IntVal=2 in section [StructVal] is mapped to StructVal.IntVal
+func INI(path string) *INICfg {
+	return &INICfg{path: path}
+}
+
+// Source returns the path of the ini file
+func (cfg *INICfg) Source() string {
+	return cfg.path
+}
+
+// Load populates ini file according to the definition of the dstCfg
+func (cfg *INICfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates ini file like Load with options in the ctx
+func (cfg *INICfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadFile(cfg.path)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, FormatINI, cfg.path, cfgBytes, dstCfg)
+}
+
+// parseINI parses sections like `[StructVal]` and lines like `IntVal = 2` or `Name: "app" ; comment`,
+// keys in sections are prefixed by the section names
+func parseINI(content string) ([]*kvLine, error) {
+	lines := []*kvLine{}
+	section := ""
+
+	for i, row := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		lineNum := i + 1
+		row = strings.TrimSpace(row)
+		if row == "" || row[0] == ';' || row[0] == '#' {
+			continue
+		}
+
+		if row[0] == '[' {
+			if row[len(row)-1] != ']' {
+				return nil, fmt.Errorf("%d: unclosed section %s", lineNum, row)
+			}
+			section = strings.TrimSpace(row[1 : len(row)-1])
+			continue
+		}
+
+		sep := strings.IndexAny(row, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("%d: missing '=' or ':'", lineNum)
+		}
+		key := strings.TrimSpace(row[:sep])
+		if key == "" {
+			return nil, fmt.Errorf("%d: missing key", lineNum)
+		}
+		if section != "" {
+			key = fmt.Sprintf("%s.%s", section, key)
+		}
+
+		val := strings.TrimSpace(row[sep+1:])
+		if len(val) > 0 && (val[0] == '"' || val[0] == '\'') {
+			quote := val[0]
+			end := closingQuote(val[1:], quote)
+			if end < 0 {
+				return nil, fmt.Errorf("%d: unclosed quote", lineNum)
+			}
+			rest := strings.TrimSpace(val[end+2:])
+			if rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, fmt.Errorf("%d: unexpected %q after quoted value", lineNum, rest)
+			}
+			val = val[1 : end+1]
+			if quote == '"' {
+				val = unescapeEnvValue(val)
+			}
+		} else {
+			for j := 0; j < len(val); j++ {
+				if (val[j] == ';' || val[j] == '#') && j > 0 && (val[j-1] == ' ' || val[j-1] == '\t') {
+					val = strings.TrimSpace(val[:j])
+					break
+				}
+			}
+		}
+
+		lines = append(lines, &kvLine{key: key, value: val, line: lineNum})
+	}
+	return lines, nil
+}
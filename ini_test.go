@@ -0,0 +1,84 @@
+package gocfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type iniConfig struct {
+	Name      string            `json:"name"`
+	IntVal    int               `json:"intVal"`
+	Labels    map[string]string `json:"labels"`
+	SliceVal  []*iniConfig      `json:"sliceVal"`
+	StructVal *iniConfig        `json:"structVal"`
+}
+
+func writeTempFile(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "gocfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestINI(t *testing.T) {
+	content := `
+; comment
+name = "my app" ; comment
+intVal: 1
+
+[StructVal]
+IntVal = 2
+labels.team = infra # comment
+
+[StructVal.SliceVal[1]]
+name = 'raw'
+`
+	path, cleanup := writeTempFile(t, "app.ini", content)
+	defer cleanup()
+
+	cfg, err := New(&iniConfig{}).Load(INI(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strs := map[string]string{
+		"Name":                       "my app",
+		"StructVal.Labels[team]":     "infra",
+		"StructVal.SliceVal[1].Name": "raw",
+	}
+	for key, val := range strs {
+		if cfg.GrabString(key) != val {
+			t.Fatalf("key %s not match: expected: %s, got: %s", key, val, cfg.GrabString(key))
+		}
+	}
+	ints := map[string]int{
+		"IntVal":           1,
+		"StructVal.IntVal": 2,
+	}
+	for key, val := range ints {
+		if cfg.GrabInt(key) != val {
+			t.Fatalf("key %s not match: expected: %d, got: %d", key, val, cfg.GrabInt(key))
+		}
+	}
+
+	path, cleanup = writeTempFile(t, "app.ini", "[StructVal]\n\nIntVal = abc\n")
+	defer cleanup()
+	_, err = New(&iniConfig{}).Load(INI(path))
+	if err == nil || err.Error() != `gocfg: `+path+`:3 StructVal.IntVal: expected int, got "abc"` {
+		t.Fatalf("error not match: %v", err)
+	}
+
+	for _, content := range []string{"[StructVal", "IntVal", `Name = "unclosed`} {
+		if _, err := parseINI(content); err == nil {
+			t.Fatalf("%s should be invalid", content)
+		}
+	}
+}
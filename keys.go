@@ -0,0 +1,204 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// KeyStrategy decides how a struct field is named in config paths
+type KeyStrategy int
+
+const (
+	// GoFieldKeys names fields by their Go field names, e.g. StructVal.IntVal
+	GoFieldKeys KeyStrategy = iota
+	// JSONTagKeys names fields by their json tags, e.g. structVal.intVal
+	JSONTagKeys
+	// YAMLTagKeys names fields by their yaml tags, e.g. structVal.intVal
+	YAMLTagKeys
+	// SnakeCaseKeys names fields by their Go field names in snake case, e.g. struct_val.int_val
+	SnakeCaseKeys
+	// LowerCaseKeys names fields by their Go field names in lower case, e.g. structval.intval
+	LowerCaseKeys
+)
+
+// WithKeyStrategy sets how struct fields are named in config paths, GoFieldKeys is used by default.
+// It also applies to the names of environment variables.
+func WithKeyStrategy(strategy KeyStrategy) Option {
+	return func(c *Cfg) {
+		c.keyStrategy = strategy
+	}
+}
+
+type keyStrategyCtxKey struct{}
+
+func withKeyStrategy(ctx context.Context, strategy KeyStrategy) context.Context {
+	return context.WithValue(ctx, keyStrategyCtxKey{}, strategy)
+}
+
+// keyStrategyOf returns the strategy in the ctx which names fields in paths of errors, GoFieldKeys by default
+func keyStrategyOf(ctx context.Context) KeyStrategy {
+	strategy, _ := ctx.Value(keyStrategyCtxKey{}).(KeyStrategy)
+	return strategy
+}
+
+// fieldName returns the name of the field in config paths
+func (c *Cfg) fieldName(field reflect.StructField) string {
+	return c.keyStrategy.fieldName(field)
+}
+
+// fieldName returns the name of the field in config paths named by the strategy
+func (s KeyStrategy) fieldName(field reflect.StructField) string {
+	switch s {
+	case JSONTagKeys:
+		return tagName(field, "json")
+	case YAMLTagKeys:
+		return tagName(field, "yaml")
+	case SnakeCaseKeys:
+		return toSnakeCase(field.Name)
+	case LowerCaseKeys:
+		return strings.ToLower(field.Name)
+	}
+	return field.Name
+}
+
+// tagName returns the name in the tag, or the field name if it is not defined
+func tagName(field reflect.StructField, tag string) string {
+	name := strings.Split(field.Tag.Get(tag), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// toSnakeCase converts a name like HTTPServerPort to http_server_port
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	words := []string{}
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(runes[i]) && (prevLower || (unicode.IsUpper(runes[i-1]) && nextLower)) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+	return strings.ToLower(strings.Join(words, "_"))
+}
+
+// WithNormalizedKeys makes getters, setters, Query and Keys resolve names of fields case-insensitively
+// and ignore "_" and "-" in them, e.g. structval.int_val finds StructVal.IntVal.
+// Map keys in brackets and ENV.* keys are matched as they are,
+// and a key matching more than one path is not resolved, ResolveKey returns the error for it.
+func WithNormalizedKeys() Option {
+	return func(c *Cfg) {
+		c.normalizeKeys = true
+	}
+}
+
+// normalizeKey lowers the key and removes "_" and "-" in it
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// normalizePath normalizes names of fields in the path by normalizeKey, segments in brackets are kept,
+// e.g. Labels[Team].Int_Val is normalized to labels[Team].intval
+func normalizePath(path string) string {
+	normalized := &strings.Builder{}
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			normalized.WriteByte('.')
+			i++
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if i+1 < len(path) && path[i+1] == '"' {
+				if _, n, err := unquotePrefix(path[i+1:]); err == nil {
+					end = 1 + n
+				}
+			}
+			if end < 0 {
+				end = len(path) - i - 1
+			}
+			normalized.WriteString(path[i : i+end+1])
+			i += end + 1
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			normalized.WriteString(normalizeKey(path[i : i+end]))
+			i += end
+		}
+	}
+	return normalized.String()
+}
+
+// comparablePath returns the path normalized if keys are normalized, ENV.* keys are not normalized
+func (c *Cfg) comparablePath(path string) string {
+	if !c.normalizeKeys || strings.HasPrefix(path, "ENV.") {
+		return path
+	}
+	return normalizePath(path)
+}
+
+// indexNormalizedKeys maps all normalized paths to the original paths
+func (c *Cfg) indexNormalizedKeys() {
+	if !c.normalizeKeys {
+		return
+	}
+
+	normalizedKeys := map[string][]string{}
+	for _, entry := range c.entries() {
+		if strings.HasPrefix(entry.Path, "ENV.") {
+			continue
+		}
+		normalized := normalizePath(entry.Path)
+		paths := normalizedKeys[normalized]
+		if len(paths) == 0 || paths[len(paths)-1] != entry.Path {
+			normalizedKeys[normalized] = append(paths, entry.Path)
+		}
+	}
+	c.normalizedKeys = normalizedKeys
+}
+
+// ResolveKey returns the path of the value named by the key,
+// it is the key itself unless keys are normalized by WithNormalizedKeys,
+// and an error is returned if the key matches more than one path after normalization
+func (c *Cfg) ResolveKey(key string) (string, error) {
+	if !c.normalizeKeys || strings.HasPrefix(key, "ENV.") {
+		return key, nil
+	}
+	paths := c.normalizedKeys[normalizePath(key)]
+	switch len(paths) {
+	case 0:
+		return key, nil
+	case 1:
+		return paths[0], nil
+	}
+	for _, path := range paths {
+		if path == key {
+			return key, nil
+		}
+	}
+	return key, fmt.Errorf("gocfg: ambiguous key %s matches %s after normalization", key, strings.Join(paths, " and "))
+}
+
+// resolve returns the original path of the key if keys are normalized,
+// the key is returned as it is and the error is logged if it is ambiguous
+func (c *Cfg) resolve(key string) string {
+	path, err := c.ResolveKey(key)
+	if err != nil {
+		c.log().Warn("ambiguous key", "key", key, "error", err)
+	}
+	return path
+}
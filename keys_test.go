@@ -0,0 +1,225 @@
+package gocfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestKeyStrategy(t *testing.T) {
+	type config struct {
+		IntVal     int     `json:"intVal" yaml:"int_val" validate:"min=1"`
+		HTTPServer string  `json:"httpServer,omitempty" yaml:"http_server" cfg:"env"`
+		Skipped    string  `json:"-"`
+		StructVal  *config `json:"structVal" yaml:"struct_val"`
+	}
+
+	input := `{"intVal": 1, "httpServer": "localhost", "structVal": {"intVal": 0}}`
+	err := os.Setenv("HTTP_SERVER", "fromEnv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("HTTP_SERVER")
+
+	strategies := []KeyStrategy{
+		GoFieldKeys,
+		JSONTagKeys,
+		YAMLTagKeys,
+		SnakeCaseKeys,
+		LowerCaseKeys,
+	}
+	outputs := [][]string{
+		{"IntVal", "HTTPServer", "Skipped", "StructVal.IntVal"},
+		{"intVal", "httpServer", "Skipped", "structVal.intVal"},
+		{"int_val", "http_server", "Skipped", "struct_val.int_val"},
+		{"int_val", "http_server", "skipped", "struct_val.int_val"},
+		{"intval", "httpserver", "skipped", "structval.intval"},
+	}
+
+	for i, strategy := range strategies {
+		_, err := New(&config{}, WithKeyStrategy(strategy)).Load(JSONStr(input))
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("error should be a ValidationError: %v", err)
+		}
+		if validationErr.Errs[0].Path != outputs[i][3] {
+			t.Fatalf("error path not match: expected: %s, got: %s", outputs[i][3], validationErr.Errs[0].Path)
+		}
+
+		input := `{"intVal": 1, "httpServer": "localhost", "structVal": {"intVal": 2}}`
+		cfg, err := New(&config{}, WithKeyStrategy(strategy)).Load(JSONStr(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GrabInt(outputs[i][0]) != 1 {
+			t.Fatalf("key %s not match: expected: 1, got: %d", outputs[i][0], cfg.GrabInt(outputs[i][0]))
+		}
+		if cfg.GrabString(outputs[i][1]) != "localhost" {
+			t.Fatalf("key %s not match: expected: localhost, got: %s", outputs[i][1], cfg.GrabString(outputs[i][1]))
+		}
+		if _, ok := cfg.String(outputs[i][2]); !ok {
+			t.Fatalf("key %s should exist", outputs[i][2])
+		}
+		if cfg.GrabInt(outputs[i][3]) != 2 {
+			t.Fatalf("key %s not match: expected: 2, got: %d", outputs[i][3], cfg.GrabInt(outputs[i][3]))
+		}
+	}
+
+	cfg, err := New(&config{}, WithKeyStrategy(SnakeCaseKeys)).Load(JSONStr(`{"intVal": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("ENV.HTTP_SERVER") != "fromEnv" {
+		t.Fatalf("key ENV.HTTP_SERVER not match: expected: fromEnv, got: %s", cfg.GrabString("ENV.HTTP_SERVER"))
+	}
+}
+
+func TestKeyStrategyErrors(t *testing.T) {
+	type item struct {
+		IntVal   int `json:"intVal" yaml:"int_val"`
+		HTTPPort int `json:"httpPort" yaml:"http_port" cfg:"alias=port"`
+	}
+	type config struct {
+		StructVal item `json:"structVal" yaml:"struct_val"`
+	}
+
+	testCases := []struct {
+		strategy KeyStrategy
+		intVal   string
+		port     string
+	}{
+		{strategy: GoFieldKeys, intVal: "StructVal.IntVal", port: "StructVal.HTTPPort"},
+		{strategy: JSONTagKeys, intVal: "structVal.intVal", port: "structVal.httpPort"},
+		{strategy: YAMLTagKeys, intVal: "struct_val.int_val", port: "struct_val.http_port"},
+		{strategy: SnakeCaseKeys, intVal: "struct_val.int_val", port: "struct_val.http_port"},
+		{strategy: LowerCaseKeys, intVal: "structval.intval", port: "structval.httpport"},
+	}
+	for _, tc := range testCases {
+		parent := strings.Split(tc.intVal, ".")[0]
+
+		// unknown keys
+		_, err := New(&config{}, WithStrict(), WithKeyStrategy(tc.strategy)).Load(YAMLStr("struct_val:\n  int_vall: 1\n"))
+		unknownKeysErr := &UnknownKeysError{}
+		if !errors.As(err, &unknownKeysErr) ||
+			unknownKeysErr.Keys[0].Path != parent+".int_vall" ||
+			unknownKeysErr.Keys[0].Suggestion != tc.intVal {
+			t.Fatalf("%d: unknown key not match: got: %v", tc.strategy, err)
+		}
+		_, err = New(&config{}, WithStrict(), WithKeyStrategy(tc.strategy)).Load(Reader(FormatProperties, strings.NewReader("StructVal.IntVall=1\n")))
+		if !errors.As(err, &unknownKeysErr) || unknownKeysErr.Keys[0].Path != parent+".IntVall" {
+			t.Fatalf("%d: unknown key not match: got: %v", tc.strategy, err)
+		}
+
+		// invalid values
+		_, err = New(&config{}, WithKeyStrategy(tc.strategy)).Load(YAMLStr("struct_val:\n  int_val: abc\n"))
+		parseErr := &ParseError{}
+		if !errors.As(err, &parseErr) || parseErr.Path != tc.intVal {
+			t.Fatalf("%d: parse error not match: got: %v", tc.strategy, err)
+		}
+
+		// deprecated keys
+		for _, pvd := range []CfgProvider{YAMLStr("struct_val:\n  port: 80\n"), DotEnvStr("STRUCTVAL_PORT=80\n")} {
+			_, err = New(&config{}, WithStrict(), WithKeyStrategy(tc.strategy)).Load(pvd)
+			deprecatedKeysErr := &DeprecatedKeysError{}
+			if !errors.As(err, &deprecatedKeysErr) ||
+				deprecatedKeysErr.Keys[0].Path != tc.port ||
+				deprecatedKeysErr.Keys[0].Message != fmt.Sprintf("use %s instead", tc.port) {
+				t.Fatalf("%d: deprecated key not match: got: %v", tc.strategy, err)
+			}
+		}
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	inputs := []string{"IntVal", "HTTPServer", "URL", "ServerID2", "already_snake"}
+	outputs := []string{"int_val", "http_server", "url", "server_id2", "already_snake"}
+	for i, input := range inputs {
+		if toSnakeCase(input) != outputs[i] {
+			t.Fatalf("%s not match: expected: %s, got: %s", input, outputs[i], toSnakeCase(input))
+		}
+	}
+}
+
+func TestNormalizedKeys(t *testing.T) {
+	type config struct {
+		IntVal    int     `json:"intVal"`
+		StructVal *config `json:"structVal"`
+	}
+
+	input := `{"intVal": 1, "structVal": {"intVal": 2}}`
+	cfg, err := New(&config{}, WithNormalizedKeys()).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"StructVal.IntVal", "structval.int_val", "STRUCT-VAL.INTVAL"}
+	for _, key := range keys {
+		if cfg.GrabInt(key) != 2 {
+			t.Fatalf("key %s not match: expected: 2, got: %d", key, cfg.GrabInt(key))
+		}
+		if val, ok := cfg.Int(key); !ok || val != 2 {
+			t.Fatalf("key %s not match: expected: 2, got: %d", key, val)
+		}
+	}
+	cfg.SetInt("struct_val.int_val", 3)
+	if cfg.GrabInt("StructVal.IntVal") != 3 {
+		t.Fatalf("key StructVal.IntVal not match: expected: 3, got: %d", cfg.GrabInt("StructVal.IntVal"))
+	}
+
+	cfg, err = New(&config{}).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Int("structval.intval"); ok {
+		t.Fatal("keys should not be normalized by default")
+	}
+
+	type ambiguousConfig struct {
+		IntVal  int `json:"intVal"`
+		Int_Val int `json:"int_val"`
+	}
+	cfg, err = New(&ambiguousConfig{}, WithNormalizedKeys()).Load(JSONStr(`{"intVal": 1, "int_val": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cfg.ResolveKey("intval"); err == nil {
+		t.Fatal("ambiguous keys should be reported")
+	}
+	if _, ok := cfg.Int("intval"); ok {
+		t.Fatal("ambiguous keys should not be resolved")
+	}
+	if cfg.GrabInt("IntVal") != 1 || cfg.GrabInt("Int_Val") != 2 {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+
+	// map keys are not normalized
+	type labelsConfig struct {
+		Labels map[string]string `json:"labels"`
+	}
+	cfg, err = New(&labelsConfig{}, WithNormalizedKeys()).
+		Load(JSONStr(`{"labels": {"Team": "a", "team": "b", "team-a": "c", "team_a": "d"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{"labels[Team]": "a", "LABELS[team]": "b", "Labels[team-a]": "c", "labels[team_a]": "d"}
+	for key, val := range labels {
+		if cfg.GrabString(key) != val {
+			t.Fatalf("key %s not match: expected: %s, got: %s", key, val, cfg.GrabString(key))
+		}
+	}
+	if !cfg.Has("labels[Team]") || cfg.Has("labels[TEAM]") || cfg.TypeOf("LABELS") != MapKind {
+		t.Fatal("keys should be resolved by Has and TypeOf")
+	}
+	if entries := cfg.Keys("labels"); len(entries) != 5 {
+		t.Fatalf("entries not match: expected: 5, got: %d", len(entries))
+	}
+	entries, err := cfg.Query("LABELS[*]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("entries not match: expected: 4, got: %d", len(entries))
+	}
+}
@@ -0,0 +1,202 @@
+package gocfg
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KVCfg is a configuration loader for a Consul-compatible key/value HTTP API
+type KVCfg struct {
+	addr   string
+	prefix string
+	token  string
+	client *http.Client
+
+	mtx   *sync.Mutex
+	index uint64
+}
+
+type kvPair struct {
+	Key   string
+	Value *string
+}
+
+// KV inits a KVCfg which reads keys under the prefix recursively from the KV API at addr, e.g. http://127.0.0.1:8500,
+// keys are mapped to paths after the prefix is trimmed, e.g. app/structval/intval with prefix app is mapped to StructVal.IntVal
+func KV(addr, prefix string) *KVCfg {
+	return &KVCfg{
+		addr:   strings.TrimSuffix(addr, "/"),
+		prefix: strings.Trim(prefix, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+		mtx:    &sync.Mutex{},
+	}
+}
+
+// Source returns the address of the KV API and the prefix, e.g. http://127.0.0.1:8500/app
+func (cfg *KVCfg) Source() string {
+	return fmt.Sprintf("%s/%s", cfg.addr, cfg.prefix)
+}
+
+// WithToken sets the ACL token of requests
+func (cfg *KVCfg) WithToken(token string) *KVCfg {
+	cfg.token = token
+	return cfg
+}
+
+// WithClient replaces the http.Client used in requests, whose timeout is 10 seconds by default
+func (cfg *KVCfg) WithClient(client *http.Client) *KVCfg {
+	cfg.client = client
+	return cfg
+}
+
+// Load populates keys under the prefix according to the definition of the dstCfg
+func (cfg *KVCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates keys like Load, and the request is canceled once the ctx is done
+func (cfg *KVCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	if _, ok := decryptionKey(ctx); ok {
+		return fmt.Errorf("gocfg: kv %s does not support decryption", cfg.prefix)
+	}
+	pairs, index, err := cfg.list(ctx, 0, 0)
+	if err != nil {
+		return err
+	}
+	cfg.mtx.Lock()
+	cfg.index = index
+	cfg.mtx.Unlock()
+
+	v := reflect.ValueOf(dstCfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("gocfg: dstCfg must be a non-nil pointer")
+	}
+	unknownKeys, deprecatedKeys := []*UnknownKey{}, []*DeprecatedKey{}
+	for _, pair := range pairs {
+		if pair.Value == nil || strings.HasSuffix(pair.Key, "/") {
+			// folders
+			continue
+		}
+		val, err := base64.StdEncoding.DecodeString(*pair.Value)
+		if err != nil {
+			return fmt.Errorf("gocfg: kv %s: %s", pair.Key, err)
+		}
+
+		key := pair.Key
+		if cfg.prefix != "" {
+			// the API also lists keys like app2/name for the prefix app
+			if !strings.HasPrefix(key, cfg.prefix+"/") {
+				continue
+			}
+			key = strings.TrimPrefix(key, cfg.prefix+"/")
+		}
+		segments := strings.Split(key, "/")
+		set, err := setByPath(v.Elem(), segments, string(val), keyStrategyOf(ctx), "")
+		if err != nil {
+			return &ParseError{Format: "kv", File: "kv", Path: set.path, Err: err}
+		}
+		if set == nil && isStrict(ctx) {
+			unknownKeys = append(unknownKeys, &UnknownKey{
+				File:       "kv",
+				Path:       unknownPath(v.Type(), segments, keyStrategyOf(ctx)),
+				Suggestion: cfg.suggest(key, v.Type(), keyStrategyOf(ctx)),
+			})
+		}
+		if set != nil && set.message != "" {
+			deprecatedKeys = append(deprecatedKeys, &DeprecatedKey{File: "kv", Key: pair.Key, Path: set.deprecatedPath, Message: set.message})
+		}
+	}
+	if err := unknownKeysErr(unknownKeys); err != nil {
+		return err
+	}
+	return reportDeprecated(ctx, deprecatedKeys)
+}
+
+// suggest returns the full key most similar to the key under the prefix, fields are named by the strategy
+func (cfg *KVCfg) suggest(key string, t reflect.Type, strategy KeyStrategy) string {
+	candidates := []string{}
+	for _, path := range knownPaths(t, strategy) {
+		if segments, err := SplitPath(path); err == nil {
+			candidates = append(candidates, strings.Join(segments, "/"))
+		}
+	}
+	similar := suggest(key, candidates)
+	if similar == "" || cfg.prefix == "" {
+		return similar
+	}
+	return fmt.Sprintf("%s/%s", cfg.prefix, similar)
+}
+
+// Wait blocks until keys under the prefix are changed since the last Load or timeout,
+// it returns true if they are changed, Load it again to apply the change.
+func (cfg *KVCfg) Wait(timeout time.Duration) (bool, error) {
+	cfg.mtx.Lock()
+	lastIndex := cfg.index
+	cfg.mtx.Unlock()
+
+	_, index, err := cfg.list(context.Background(), lastIndex, timeout)
+	if err != nil {
+		return false, err
+	}
+	return index != lastIndex, nil
+}
+
+// list lists keys under the prefix, it is a blocking query if index is not 0
+func (cfg *KVCfg) list(ctx context.Context, index uint64, wait time.Duration) ([]*kvPair, uint64, error) {
+	query := url.Values{}
+	query.Set("recurse", "true")
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%dms", wait.Milliseconds()))
+	}
+	reqURL := fmt.Sprintf("%s/v1/kv/%s?%s", cfg.addr, cfg.prefix, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cfg.token != "" {
+		req.Header.Set("X-Consul-Token", cfg.token)
+	}
+
+	client := cfg.client
+	if wait > 0 && client.Timeout > 0 {
+		// the server holds blocking queries for the wait at most
+		copied := *client
+		copied.Timeout += wait
+		client = &copied
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		// no key under the prefix
+		return []*kvPair{}, newIndex, nil
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, 0, err
+		}
+		pairs := []*kvPair{}
+		if err = json.Unmarshal(body, &pairs); err != nil {
+			return nil, 0, err
+		}
+		return pairs, newIndex, nil
+	}
+	return nil, 0, fmt.Errorf("gocfg: failed to list %s: %s", reqURL, resp.Status)
+}
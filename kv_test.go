@@ -0,0 +1,150 @@
+package gocfg
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKV is a minimal Consul-compatible KV server
+type fakeKV struct {
+	mtx     *sync.Mutex
+	cond    *sync.Cond
+	index   uint64
+	entries map[string]string
+}
+
+func newFakeKV() *fakeKV {
+	mtx := &sync.Mutex{}
+	return &fakeKV{
+		mtx:     mtx,
+		cond:    sync.NewCond(mtx),
+		index:   1,
+		entries: map[string]string{},
+	}
+}
+
+func (kv *fakeKV) put(key, val string) {
+	kv.mtx.Lock()
+	defer kv.mtx.Unlock()
+	kv.entries[key] = val
+	kv.index++
+	kv.cond.Broadcast()
+}
+
+func (kv *fakeKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+	kv.mtx.Lock()
+	defer kv.mtx.Unlock()
+	if index, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); err == nil {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		timer := time.AfterFunc(wait, func() {
+			kv.mtx.Lock()
+			defer kv.mtx.Unlock()
+			kv.cond.Broadcast()
+		})
+		defer timer.Stop()
+
+		deadline := time.Now().Add(wait)
+		for kv.index == index && time.Now().Before(deadline) {
+			kv.cond.Wait()
+		}
+	}
+
+	keys := []string{}
+	for key := range kv.entries {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(kv.index, 10))
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	pairs := []map[string]interface{}{}
+	for _, key := range keys {
+		val := base64.StdEncoding.EncodeToString([]byte(kv.entries[key]))
+		pairs = append(pairs, map[string]interface{}{"Key": key, "Value": val})
+	}
+	pairs = append(pairs, map[string]interface{}{"Key": prefix + "/folder/", "Value": nil})
+	json.NewEncoder(w).Encode(pairs)
+}
+
+func TestKV(t *testing.T) {
+	type config struct {
+		Name      string            `json:"name"`
+		IntVal    int               `json:"intVal"`
+		Labels    map[string]string `json:"labels"`
+		StructVal *config           `json:"structVal"`
+	}
+
+	kv := newFakeKV()
+	kv.put("app/name", "my app")
+	kv.put("app/structval/int_val", "2")
+	kv.put("app/labels/team", "infra")
+	kv.put("other/name", "other")
+	kv.put("apple/name", "apple")
+	server := httptest.NewServer(kv)
+	defer server.Close()
+
+	pvd := KV(server.URL, "app").WithToken("token")
+	cfg, err := New(&config{}).Load(pvd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "my app" || cfg.GrabInt("StructVal.IntVal") != 2 || cfg.GrabString("Labels[team]") != "infra" {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+	// keys under apple/ are not under the prefix app
+	if _, err = New(&config{}, WithStrict()).Load(KV(server.URL, "app").WithToken("token")); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := pvd.Wait(50 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	} else if changed {
+		t.Fatal("config should not be changed")
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		kv.put("app/intval", "3")
+	}()
+	changed, err = pvd.Wait(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	} else if !changed {
+		t.Fatal("config should be changed")
+	}
+	cfg, err = New(&config{}).Load(pvd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabInt("IntVal") != 3 {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+
+	kv.put("app/intval", "abc")
+	if _, err = New(&config{}).Load(pvd); err == nil || err.Error() != `gocfg: kv IntVal: expected int, got "abc"` {
+		t.Fatalf("error not match: %v", err)
+	}
+	if _, err = New(&config{}).Load(KV(server.URL, "app")); err == nil {
+		t.Fatal("forbidden request should be reported")
+	}
+}
@@ -0,0 +1,72 @@
+package gocfg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Logger receives logs of loading configs, args are key value pairs like "path", "StructVal.IntVal",
+// so a *slog.Logger can be used as a Logger directly
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// WithLogger makes the Cfg write logs to the logger, logs of all levels are sent to it
+func WithLogger(logger Logger) Option {
+	return func(c *Cfg) {
+		c.logger = logger
+	}
+}
+
+// log returns the logger of the Cfg,
+// logs are written to stderr in debug mode if no logger is set, or they are discarded
+func (c *Cfg) log() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	if c.debug {
+		return stderrLogger
+	}
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+func (nopLogger) Info(msg string, args ...interface{})  {}
+func (nopLogger) Warn(msg string, args ...interface{})  {}
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+var stderrLogger = &textLogger{out: os.Stderr, mtx: &sync.Mutex{}}
+
+// textLogger writes logs as lines like `gocfg: WARN msg path=StructVal.IntVal`
+type textLogger struct {
+	out io.Writer
+	mtx *sync.Mutex
+}
+
+func (l *textLogger) Debug(msg string, args ...interface{}) { l.write("DEBUG", msg, args) }
+func (l *textLogger) Info(msg string, args ...interface{})  { l.write("INFO", msg, args) }
+func (l *textLogger) Warn(msg string, args ...interface{})  { l.write("WARN", msg, args) }
+func (l *textLogger) Error(msg string, args ...interface{}) { l.write("ERROR", msg, args) }
+
+func (l *textLogger) write(level, msg string, args []interface{}) {
+	row := []string{"gocfg:", level, msg}
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			row = append(row, fmt.Sprintf("%v", args[i]))
+			break
+		}
+		row = append(row, fmt.Sprintf("%v=%s", args[i], quoteEnvValue(fmt.Sprintf("%v", args[i+1]))))
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	fmt.Fprintln(l.out, strings.Join(row, " "))
+}
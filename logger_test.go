@@ -0,0 +1,111 @@
+package gocfg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordedLog struct {
+	level string
+	msg   string
+	attrs map[string]string
+}
+
+type recordLogger struct {
+	mtx  *sync.Mutex
+	logs []*recordedLog
+}
+
+func newRecordLogger() *recordLogger {
+	return &recordLogger{mtx: &sync.Mutex{}, logs: []*recordedLog{}}
+}
+
+func (l *recordLogger) record(level, msg string, args []interface{}) {
+	attrs := map[string]string{}
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[fmt.Sprintf("%v", args[i])] = fmt.Sprintf("%v", args[i+1])
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.logs = append(l.logs, &recordedLog{level: level, msg: msg, attrs: attrs})
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args) }
+
+func (l *recordLogger) find(level, msg string) []*recordedLog {
+	found := []*recordedLog{}
+	for _, log := range l.logs {
+		if log.level == level && log.msg == msg {
+			found = append(found, log)
+		}
+	}
+	return found
+}
+
+func TestLogger(t *testing.T) {
+	type config struct {
+		Name   string  `json:"name"`
+		IntVal int     `json:"intVal"`
+		PtrVal *string `json:"ptrVal"`
+	}
+
+	logger := newRecordLogger()
+	cfg := New(&config{}, WithLogger(logger))
+	_, err := cfg.Load(
+		JSONStr(`{"name": "a", "intVal": 1}`),
+		JSONStr(`{"name": "b"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded := logger.find("INFO", "provider loaded"); len(loaded) != 2 || loaded[0].attrs["provider"] != "JSONStrCfg" {
+		t.Fatalf("provider logs not match: got: %d", len(loaded))
+	}
+	overridden := logger.find("INFO", "value overridden")
+	if len(overridden) != 1 || overridden[0].attrs["path"] != "Name" {
+		t.Fatalf("override logs not match: got: %d", len(overridden))
+	}
+	if zero := logger.find("DEBUG", "zero value"); len(zero) == 0 || zero[0].attrs["path"] != "PtrVal" {
+		t.Fatal("zero value should be logged")
+	}
+	if len(logger.find("INFO", "config loaded")) != 1 {
+		t.Fatal("load should be logged")
+	}
+
+	if _, err = cfg.Load(JSONStr(`{"intVal": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.find("INFO", "config reloaded")) != 1 {
+		t.Fatal("reload should be logged")
+	}
+
+	if _, err = cfg.Load(JSONStr(`{`)); err == nil {
+		t.Fatal("invalid json should be reported")
+	}
+	if failed := logger.find("ERROR", "load failed"); len(failed) != 1 || failed[0].attrs["error"] != err.Error() {
+		t.Fatal("failure should be logged")
+	}
+}
+
+func TestTextLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := &textLogger{out: buf, mtx: &sync.Mutex{}}
+	logger.Warn("value overridden", "path", "StructVal.IntVal", "provider", "YAMLCfg(app dev.yaml)")
+	logger.Info("odd", "key")
+
+	expected := strings.Join([]string{
+		`gocfg: WARN value overridden path=StructVal.IntVal provider="YAMLCfg(app dev.yaml)"`,
+		"gocfg: INFO odd key",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("logs not match: expected: %s, got: %s", expected, buf.String())
+	}
+}
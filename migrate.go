@@ -0,0 +1,218 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// GocfgVersionKey is the top level key of the schema version in a config,
+// a config without it is at version 1 and it is not written back as it may be a partial overlay,
+// and the template should have a field for it, e.g.
+// Version int `json:"version" yaml:"version" toml:"version"`
+var GocfgVersionKey = "version"
+
+// Migration upgrades a decoded config document by one version, e.g. it renames or moves keys
+type Migration func(doc map[string]interface{}) error
+
+// Migrations is a registry of migrations which upgrade configs to the latest version
+type Migrations struct {
+	steps  map[int]Migration
+	latest int
+}
+
+// NewMigrations returns an empty registry, configs are at version 1 until migrations are registered
+func NewMigrations() *Migrations {
+	return &Migrations{steps: map[int]Migration{}, latest: 1}
+}
+
+// Register adds the migration which upgrades a config from the version from to from+1
+func (m *Migrations) Register(from int, migrate Migration) *Migrations {
+	m.steps[from] = migrate
+	if from+1 > m.latest {
+		m.latest = from + 1
+	}
+	return m
+}
+
+// Latest returns the latest version of configs
+func (m *Migrations) Latest() int {
+	return m.latest
+}
+
+// Migrate upgrades the doc to the latest version and sets the version in it,
+// it returns the version of the doc before migration
+func (m *Migrations) Migrate(doc map[string]interface{}) (int, error) {
+	from, err := m.migrate(doc)
+	if err != nil {
+		return from, fmt.Errorf("gocfg: %w", err)
+	}
+	return from, nil
+}
+
+func (m *Migrations) migrate(doc map[string]interface{}) (int, error) {
+	from, err := docVersion(doc)
+	if err != nil {
+		return 0, err
+	}
+	if from > m.latest {
+		return from, fmt.Errorf("version %d is newer than the latest version %d", from, m.latest)
+	}
+
+	for version := from; version < m.latest; version++ {
+		migrate, ok := m.steps[version]
+		if !ok {
+			return from, fmt.Errorf("no migration from version %d", version)
+		}
+		if err = migrate(doc); err != nil {
+			return from, fmt.Errorf("migrate from version %d: %w", version, err)
+		}
+	}
+	if from != m.latest {
+		doc[GocfgVersionKey] = m.latest
+	}
+	return from, nil
+}
+
+// docVersion returns the version in the doc, it is 1 if the doc has no version
+func docVersion(doc map[string]interface{}) (int, error) {
+	val, ok := doc[GocfgVersionKey]
+	if !ok || val == nil {
+		return 1, nil
+	}
+
+	switch version := val.(type) {
+	case int:
+		return version, nil
+	case int64:
+		return int(version), nil
+	case uint64:
+		return int(version), nil
+	case float64:
+		if version == math.Trunc(version) {
+			return int(version), nil
+		}
+	case string:
+		if parsed, err := strconv.Atoi(version); err == nil {
+			return parsed, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid version %v", val)
+}
+
+// WithMigrations makes Load upgrade json, yaml and toml configs to the latest version with the migrations,
+// they are applied to the decoded document before it is populated to the template
+func WithMigrations(migrations *Migrations) Option {
+	return func(c *Cfg) {
+		c.migrations = migrations
+	}
+}
+
+// WithWriteMigrated makes Load write migrated configs back to their local files loaded by JSON or YAML,
+// files are written only after all providers are loaded and the config is validated,
+// files without the version key are not written, and comments and the order of keys are not kept in the files written
+func WithWriteMigrated() Option {
+	return func(c *Cfg) {
+		c.writeMigrated = true
+	}
+}
+
+type migrationsCtxKey struct{}
+type localFileCtxKey struct{}
+
+// migrator applies migrations to configs loaded with the ctx,
+// and keeps migrated local files until they are written by flush
+type migrator struct {
+	migrations *Migrations
+	writeBack  bool
+	mtx        *sync.Mutex
+	names      []string
+	pending    map[string][]byte
+}
+
+func newMigrator(migrations *Migrations, writeBack bool) *migrator {
+	return &migrator{
+		migrations: migrations,
+		writeBack:  writeBack,
+		mtx:        &sync.Mutex{},
+		names:      []string{},
+		pending:    map[string][]byte{},
+	}
+}
+
+func withMigrations(ctx context.Context, m *migrator) context.Context {
+	return context.WithValue(ctx, migrationsCtxKey{}, m)
+}
+
+// withLocalFile marks that the config is read from a local file which migrated content can be written to
+func withLocalFile(ctx context.Context) context.Context {
+	return context.WithValue(ctx, localFileCtxKey{}, true)
+}
+
+// write keeps the migrated content of the file named name until flush
+func (m *migrator) write(name string, content []byte) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if _, ok := m.pending[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.pending[name] = content
+}
+
+// flush writes migrated files kept by write, it is called once the config is loaded and validated
+func (m *migrator) flush() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, name := range m.names {
+		info, err := os.Stat(name)
+		if err != nil {
+			return fmt.Errorf("gocfg: %w", err)
+		}
+		if err = ioutil.WriteFile(name, m.pending[name], info.Mode().Perm()); err != nil {
+			return fmt.Errorf("gocfg: %w", err)
+		}
+	}
+	m.names, m.pending = []string{}, map[string][]byte{}
+	return nil
+}
+
+// migrateDocument upgrades the json, yaml or toml content named name if there are migrations in the ctx,
+// the content is returned as it is if it is at the latest version or it is invalid.
+// The migrated content is re-encoded, so it also returns the name for positions in it, which tells they refer to the migrated content.
+func migrateDocument(ctx context.Context, format, name string, content []byte) ([]byte, string, error) {
+	m, ok := ctx.Value(migrationsCtxKey{}).(*migrator)
+	if !ok || m.migrations == nil {
+		return content, name, nil
+	}
+
+	if format != FormatJSON && format != FormatYAML && format != FormatTOML {
+		return content, name, nil
+	}
+	doc, err := DecodeDocument(format, content)
+	if err != nil {
+		// errors are reported in decoding with their positions
+		return content, name, nil
+	}
+
+	_, versioned := doc[GocfgVersionKey]
+	from, err := m.migrations.migrate(doc)
+	if err != nil {
+		return nil, name, &ParseError{Format: format, File: name, Err: err}
+	}
+	if from == m.migrations.Latest() {
+		return content, name, nil
+	}
+
+	migrated, err := EncodeDocument(format, doc)
+	if err != nil {
+		return nil, name, err
+	}
+	if localFile, _ := ctx.Value(localFileCtxKey{}).(bool); m.writeBack && localFile && versioned {
+		m.write(name, migrated)
+	}
+	return migrated, fmt.Sprintf("%s (migrated from version %d to %d)", name, from, m.migrations.Latest()), nil
+}
@@ -0,0 +1,213 @@
+package gocfg
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type migratedConfig struct {
+	Version int             `json:"version" yaml:"version" toml:"version"`
+	Server  *migratedServer `json:"server" yaml:"server" toml:"server"`
+}
+
+type migratedServer struct {
+	Host string `json:"host" yaml:"host" toml:"host"`
+	Port int    `json:"port" yaml:"port" toml:"port"`
+}
+
+// testMigrations upgrades v1 configs with a top level addr, e.g. addr: "localhost:80",
+// to v2 configs with server.addr, and then to v3 configs with server.host and server.port
+func testMigrations() *Migrations {
+	return NewMigrations().
+		Register(1, func(doc map[string]interface{}) error {
+			doc["server"] = map[string]interface{}{"addr": doc["addr"]}
+			delete(doc, "addr")
+			return nil
+		}).
+		Register(2, func(doc map[string]interface{}) error {
+			server, ok := doc["server"].(map[string]interface{})
+			if !ok {
+				return errors.New("server not found")
+			}
+			addr := fmt.Sprint(server["addr"])
+			parts := strings.Split(addr, ":")
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid addr %s", addr)
+			}
+			port, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return err
+			}
+			server["host"], server["port"] = parts[0], port
+			delete(server, "addr")
+			return nil
+		})
+}
+
+func TestMigrations(t *testing.T) {
+	testCases := []struct {
+		name string
+		pvd  CfgProvider
+	}{
+		{name: "v1 yaml", pvd: YAMLStr("addr: localhost:80\n")},
+		{name: "v2 yaml", pvd: YAMLStr("version: 2\nserver:\n  addr: localhost:80\n")},
+		{name: "v3 yaml", pvd: YAMLStr("version: 3\nserver:\n  host: localhost\n  port: 80\n")},
+		{name: "v1 json", pvd: JSONStr(`{"version": 1, "addr": "localhost:80"}`)},
+		{name: "v2 toml", pvd: Reader(FormatTOML, strings.NewReader("version = 2\n[server]\naddr = \"localhost:80\"\n"))},
+	}
+
+	for _, tc := range testCases {
+		cfg, err := New(&migratedConfig{}, WithMigrations(testMigrations())).Load(tc.pvd)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if cfg.GrabInt("Version") != 3 {
+			t.Fatalf("%s: version not match: expected: 3, got: %d", tc.name, cfg.GrabInt("Version"))
+		}
+		if cfg.GrabString("Server.Host") != "localhost" || cfg.GrabInt("Server.Port") != 80 {
+			t.Fatalf("%s: config not match: got: %s", tc.name, cfg.ToString())
+		}
+	}
+
+	errCases := []struct {
+		name     string
+		pvd      CfgProvider
+		expected string
+	}{
+		{
+			name:     "newer version",
+			pvd:      YAMLStr("version: 4\n"),
+			expected: "gocfg: yaml: version 4 is newer than the latest version 3",
+		},
+		{
+			name:     "invalid version",
+			pvd:      YAMLStr("version: v1\n"),
+			expected: "gocfg: yaml: invalid version v1",
+		},
+		{
+			// positions refer to the content as it is if no migration applies
+			name:     "latest version",
+			pvd:      YAMLStr("version: 3\n\nserver:\n  host: localhost\n  port: abc\n"),
+			expected: `gocfg: yaml:5:9 Server.Port: expected int, got "abc"`,
+		},
+		{
+			name:     "failed migration",
+			pvd:      JSONStr(`{"addr": "localhost"}`),
+			expected: "gocfg: json: migrate from version 2: invalid addr localhost",
+		},
+	}
+	for _, tc := range errCases {
+		_, err := New(&migratedConfig{}, WithMigrations(testMigrations())).Load(tc.pvd)
+		if err == nil || err.Error() != tc.expected {
+			t.Fatalf("%s: error not match: expected: %s, got: %v", tc.name, tc.expected, err)
+		}
+	}
+
+	// positions refer to the migrated content, and the name tells it
+	_, err := New(&migratedConfig{}, WithMigrations(testMigrations()), WithStrict()).
+		Load(YAMLStr("version: 2\nserver:\n  addr: localhost:80\n  nmae: app\n"))
+	unknownKeysErr := &UnknownKeysError{}
+	if !errors.As(err, &unknownKeysErr) || len(unknownKeysErr.Keys) != 1 ||
+		unknownKeysErr.Keys[0].File != "yaml (migrated from version 2 to 3)" {
+		t.Fatalf("error not match: got: %v", err)
+	}
+
+	_, err = New(&migratedConfig{}, WithMigrations(NewMigrations().Register(2, testMigrations().steps[2]))).
+		Load(YAMLStr("addr: localhost:80\n"))
+	if err == nil || err.Error() != "gocfg: yaml: no migration from version 1" {
+		t.Fatalf("error not match: got: %v", err)
+	}
+}
+
+func TestWriteMigrated(t *testing.T) {
+	original := `{"version": 1, "addr": "localhost:80"}`
+	path, cleanup := writeTempFile(t, "app.json", original)
+	defer cleanup()
+	overlayPath, cleanupOverlay := writeTempFile(t, "overlay.json", `{"addr": "localhost:80"}`)
+	defer cleanupOverlay()
+
+	type validatedServer struct {
+		Port int `json:"port" validate:"max=79"`
+	}
+	type validatedConfig struct {
+		Version int              `json:"version"`
+		Server  *validatedServer `json:"server"`
+	}
+
+	// files are not written if they are not asked to be written, or the config fails to load
+	loads := map[string]func() error{
+		"without WithWriteMigrated": func() error {
+			_, err := New(&migratedConfig{}, WithMigrations(testMigrations())).Load(JSON(path))
+			return err
+		},
+		"failed provider": func() error {
+			_, err := New(&migratedConfig{}, WithMigrations(testMigrations()), WithWriteMigrated()).
+				Load(JSON(path), JSONStr("{"))
+			if err == nil {
+				return errors.New("invalid provider should be reported")
+			}
+			return nil
+		},
+		"invalid config": func() error {
+			_, err := New(&validatedConfig{}, WithMigrations(testMigrations()), WithWriteMigrated()).Load(JSON(path))
+			if err == nil {
+				return errors.New("invalid config should be reported")
+			}
+			return nil
+		},
+	}
+	for name, load := range loads {
+		if err := load(); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != original {
+			t.Fatalf("%s: file should not be written: got: %s", name, content)
+		}
+	}
+
+	// files without the version are migrated but not written
+	cfg, err := New(&migratedConfig{}, WithMigrations(testMigrations()), WithWriteMigrated()).Load(JSON(overlayPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabInt("Server.Port") != 80 {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+	content, err := ioutil.ReadFile(overlayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"addr": "localhost:80"}` {
+		t.Fatalf("file without version should not be written: got: %s", content)
+	}
+
+	_, err = New(&migratedConfig{}, WithMigrations(testMigrations()), WithWriteMigrated()).Load(JSON(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"server\": {\n    \"host\": \"localhost\",\n    \"port\": 80\n  },\n  \"version\": 3\n}"
+	if string(content) != expected {
+		t.Fatalf("file not match: expected: %s, got: %s", expected, content)
+	}
+
+	// the migrated file is at the latest version
+	cfg, err = New(&migratedConfig{}, WithMigrations(testMigrations()), WithWriteMigrated()).Load(JSON(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabInt("Version") != 3 || cfg.GrabString("Server.Host") != "localhost" {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+}
@@ -0,0 +1,374 @@
+package gocfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// jsonNode parses the json content as a yaml node by the json decoder, so any valid json is accepted,
+// and keys and values are positioned by their offsets in the content
+func jsonNode(content []byte) (*yaml.Node, error) {
+	var val interface{}
+	if err := json.Unmarshal(content, &val); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	return jsonValueNode(dec, content)
+}
+
+// jsonValueNode converts the next value in the dec to a yaml node
+func jsonValueNode(dec *json.Decoder, content []byte) (*yaml.Node, error) {
+	line, column := position(content, jsonTokenOffset(content, dec.InputOffset()))
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode, Line: line, Column: column}
+	switch val := token.(type) {
+	case json.Delim:
+		node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+		if val == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				keyNode, err := jsonValueNode(dec, content)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, keyNode)
+			}
+			child, err := jsonValueNode(dec, content)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		// the closing delimiter
+		if _, err = dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.Tag, node.Value, node.Style = "!!str", val, yaml.DoubleQuotedStyle
+	case json.Number:
+		node.Tag, node.Value = "!!int", val.String()
+		if strings.ContainsAny(node.Value, ".eE") {
+			node.Tag = "!!float"
+		}
+	case bool:
+		node.Tag, node.Value = "!!bool", strconv.FormatBool(val)
+	case nil:
+		node.Tag, node.Value = "!!null", "null"
+	}
+	return node, nil
+}
+
+// jsonTokenOffset returns the offset of the token following the offset,
+// spaces and separators before it are skipped
+func jsonTokenOffset(content []byte, offset int64) int64 {
+	for offset < int64(len(content)) && strings.IndexByte(" \t\r\n,:", content[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// tomlNode parses the toml content as a yaml node, keys are in the order of the content,
+// and keys are positioned by scanning the content as the toml decoder does not export positions
+func tomlNode(content []byte) (*yaml.Node, error) {
+	doc := map[string]interface{}{}
+	md, err := toml.Decode(string(content), &doc)
+	if err != nil {
+		return nil, err
+	}
+	builder := &tomlBuilder{order: map[string]int{}, positions: scanTOMLKeys(content)}
+	for i, key := range md.Keys() {
+		if _, ok := builder.order[key.String()]; !ok {
+			builder.order[key.String()] = i
+		}
+	}
+	return builder.node(doc, toml.Key{}, 0)
+}
+
+// tomlBuilder converts a decoded toml document to a yaml node
+type tomlBuilder struct {
+	order     map[string]int
+	positions map[string][]*tomlPosition // positions of keys in the order of the content
+}
+
+// tomlPosition is the position of a key in toml content
+type tomlPosition struct {
+	line   int
+	column int
+}
+
+// next returns the position of the next occurrence of the key, it is consumed unless peek is true
+func (b *tomlBuilder) next(key toml.Key, peek bool) *tomlPosition {
+	positions := b.positions[key.String()]
+	if len(positions) == 0 {
+		return nil
+	}
+	if !peek && len(positions) > 1 {
+		b.positions[key.String()] = positions[1:]
+	}
+	return positions[0]
+}
+
+// node converts the toml value named by the key to a yaml node,
+// line is the line of the key, which is also used for keys in the value not found in the content
+func (b *tomlBuilder) node(val interface{}, key toml.Key, line int) (*yaml.Node, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		names := []string{}
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return b.order[childKey(key, names[i]).String()] < b.order[childKey(key, names[j]).String()]
+		})
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line}
+		for _, name := range names {
+			child := childKey(key, name)
+			_, isTables := v[name].([]map[string]interface{})
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: line}
+			if pos := b.next(child, isTables); pos != nil {
+				keyNode.Line, keyNode.Column = pos.line, pos.column
+			}
+			valNode, err := b.node(v[name], child, keyNode.Line)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, keyNode, valNode)
+		}
+		return node, nil
+	case []map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, item := range v {
+			// each table in the array is positioned at its header
+			itemLine := line
+			if pos := b.next(key, false); pos != nil {
+				itemLine = pos.line
+			}
+			child, err := b.node(item, key, itemLine)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, item := range v {
+			child, err := b.node(item, key, line)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(val); err != nil {
+		return nil, err
+	}
+	node.Line = line
+	return node, nil
+}
+
+// childKey returns the key of the child named name without changing the parent key
+func childKey(parent toml.Key, name string) toml.Key {
+	return append(parent[:len(parent):len(parent)], name)
+}
+
+// scanTOMLKeys returns positions of keys and table headers in the toml content by their full keys,
+// keys in inline tables are not scanned and parent keys implied by dotted keys are positioned at their first use
+func scanTOMLKeys(content []byte) map[string][]*tomlPosition {
+	positions := map[string][]*tomlPosition{}
+	record := func(key toml.Key, pos *tomlPosition, implied bool) {
+		if implied && len(positions[key.String()]) > 0 {
+			return
+		}
+		positions[key.String()] = append(positions[key.String()], pos)
+	}
+
+	s := &tomlScanner{content: content, line: 1, lineStart: 0}
+	table := toml.Key{}
+	for s.skipSpaces(true); s.i < len(content); s.skipSpaces(true) {
+		pos := s.position()
+		if content[s.i] == '[' {
+			isArray := s.i+1 < len(content) && content[s.i+1] == '['
+			s.i++
+			if isArray {
+				s.i++
+			}
+			table = s.key()
+			for i := 1; i < len(table); i++ {
+				record(table[:i], pos, true)
+			}
+			record(table, pos, false)
+			s.skipLine()
+			continue
+		}
+
+		key := s.key()
+		if len(key) == 0 || s.i >= len(content) || content[s.i] != '=' {
+			// invalid content is reported by the decoder
+			s.skipLine()
+			continue
+		}
+		full := append(table[:len(table):len(table)], key...)
+		for i := len(table) + 1; i < len(full); i++ {
+			record(full[:i], pos, true)
+		}
+		record(full, pos, false)
+		s.i++
+		s.skipValue()
+	}
+	return positions
+}
+
+// tomlScanner scans keys in toml content
+type tomlScanner struct {
+	content   []byte
+	i         int
+	line      int
+	lineStart int
+}
+
+func (s *tomlScanner) position() *tomlPosition {
+	return &tomlPosition{line: s.line, column: s.i - s.lineStart + 1}
+}
+
+func (s *tomlScanner) newline() {
+	s.i++
+	s.line, s.lineStart = s.line+1, s.i
+}
+
+// skipSpaces skips spaces and comments, and newlines if multiline is true
+func (s *tomlScanner) skipSpaces(multiline bool) {
+	for s.i < len(s.content) {
+		switch c := s.content[s.i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			s.i++
+		case c == '#':
+			for s.i < len(s.content) && s.content[s.i] != '\n' {
+				s.i++
+			}
+		case c == '\n' && multiline:
+			s.newline()
+		default:
+			return
+		}
+	}
+}
+
+// skipLine skips the rest of the line
+func (s *tomlScanner) skipLine() {
+	for s.i < len(s.content) && s.content[s.i] != '\n' {
+		s.i++
+	}
+}
+
+// key scans a dotted key and the following spaces
+func (s *tomlScanner) key() toml.Key {
+	key := toml.Key{}
+	for {
+		s.skipSpaces(false)
+		if s.i >= len(s.content) {
+			return key
+		}
+		switch c := s.content[s.i]; {
+		case c == '"' || c == '\'':
+			key = append(key, s.quoted(c))
+		default:
+			start := s.i
+			for s.i < len(s.content) && isBareKeyChar(s.content[s.i]) {
+				s.i++
+			}
+			if s.i == start {
+				return key
+			}
+			key = append(key, string(s.content[start:s.i]))
+		}
+		s.skipSpaces(false)
+		if s.i >= len(s.content) || s.content[s.i] != '.' {
+			return key
+		}
+		s.i++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// quoted scans a single line string quoted by the quote and returns its value
+func (s *tomlScanner) quoted(quote byte) string {
+	start := s.i
+	for s.i++; s.i < len(s.content) && s.content[s.i] != quote && s.content[s.i] != '\n'; s.i++ {
+		if quote == '"' && s.content[s.i] == '\\' {
+			s.i++
+		}
+	}
+	s.i++
+	if quote == '\'' {
+		return string(s.content[start+1 : s.i-1])
+	}
+	val, err := strconv.Unquote(string(s.content[start:s.i]))
+	if err != nil {
+		return string(s.content[start+1 : s.i-1])
+	}
+	return val
+}
+
+// skipValue skips a value, which may span lines in multiline strings and arrays, and the rest of its line
+func (s *tomlScanner) skipValue() {
+	depth := 0
+	for s.i < len(s.content) {
+		switch c := s.content[s.i]; {
+		case c == '"' || c == '\'':
+			quotes := string([]byte{c, c, c})
+			if bytes.HasPrefix(s.content[s.i:], []byte(quotes)) {
+				s.i += 3
+				for s.i < len(s.content) && !bytes.HasPrefix(s.content[s.i:], []byte(quotes)) {
+					if c == '"' && s.content[s.i] == '\\' {
+						// escaped characters, or line ending backslashes
+						s.i++
+					}
+					if s.i < len(s.content) && s.content[s.i] == '\n' {
+						s.newline()
+						continue
+					}
+					s.i++
+				}
+				s.i += 3
+				continue
+			}
+			s.quoted(c)
+		case c == '[' || c == '{':
+			depth++
+			s.i++
+		case c == ']' || c == '}':
+			depth--
+			s.i++
+		case c == '#':
+			s.skipSpaces(false)
+		case c == '\n':
+			if depth <= 0 {
+				return
+			}
+			s.newline()
+		default:
+			s.i++
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package gocfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+)
+
+// ErrNotFound is returned by remote providers when the config does not exist
+var ErrNotFound = errors.New("gocfg: config not found")
+
+// isNotFound checks if the err means that the config does not exist,
+// errors of reading other files, e.g. a missing key file, are excluded
+func isNotFound(err error) bool {
+	var keyErr *keySourceError
+	if errors.As(err, &keyErr) {
+		return false
+	}
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrNotFound)
+}
+
+type reportCtxKey struct{}
+
+// loadReport describes what was applied in a load of a provider,
+// it is created for every load so that providers shared by concurrent loads report separately
+type loadReport struct {
+	result string
+}
+
+func withReport(ctx context.Context, report *loadReport) context.Context {
+	return context.WithValue(ctx, reportCtxKey{}, report)
+}
+
+// setReport records what was applied by the provider loaded with the ctx
+func setReport(ctx context.Context, result string) {
+	if report, ok := ctx.Value(reportCtxKey{}).(*loadReport); ok {
+		report.result = result
+	}
+}
+
+// loadReported loads pvd and returns what was applied by it
+func loadReported(ctx context.Context, pvd ContextProvider, dstCfg interface{}) (string, error) {
+	report := &loadReport{}
+	err := pvd.Load(withReport(ctx, report), dstCfg)
+	if report.result == "" {
+		report.result = fmt.Sprintf("applied %s", describe(unwrap(pvd)))
+	}
+	return report.result, err
+}
+
+// unwrap returns the provider wrapped by Retry or Timeout, which names what is loaded in reports
+func unwrap(pvd ContextProvider) ContextProvider {
+	for {
+		switch wrapper := pvd.(type) {
+		case *RetryCfg:
+			pvd = wrapper.pvd
+		case *TimeoutCfg:
+			pvd = wrapper.pvd
+		default:
+			return pvd
+		}
+	}
+}
+
+// sourcer is implemented by providers which can tell where configs are loaded from, e.g. a path or an url
+type sourcer interface {
+	Source() string
+}
+
+// describe names the provider with its source, e.g. JSONCfg(app.json)
+func describe(pvd interface{}) string {
+	if adapter, ok := pvd.(*ContextCfg); ok {
+		return describe(adapter.pvd)
+	}
+	t := reflect.TypeOf(pvd)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := pvd.(sourcer); ok {
+		return fmt.Sprintf("%s(%s)", t.Name(), s.Source())
+	}
+	return t.Name()
+}
+
+// loadOrKeep loads pvd into a copy of dstCfg and applies it only if loading succeeds
+func loadOrKeep(ctx context.Context, pvd ContextProvider, dstCfg interface{}) (string, error) {
+	dstVal := reflect.ValueOf(dstCfg)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() {
+		return "", fmt.Errorf("gocfg: dstCfg must be a non-nil pointer")
+	}
+	cloned := cloneValue(dstVal)
+	result, err := loadReported(ctx, pvd, cloned.Interface())
+	if err != nil {
+		return "", err
+	}
+	dstVal.Elem().Set(cloned.Elem())
+	return result, nil
+}
+
+// OptionalCfg is a configuration loader which skips the provider if its config does not exist
+type OptionalCfg struct {
+	pvd ContextProvider
+}
+
+// Optional inits an OptionalCfg for the pvd, e.g. Optional(YAML("/etc/app.yaml")) is a no-op if the file is absent
+func Optional(pvd CfgProvider) *OptionalCfg {
+	return &OptionalCfg{pvd: Contextual(pvd)}
+}
+
+// OptionalContext inits an OptionalCfg for the pvd like Optional, e.g. OptionalContext(Retry(...))
+func OptionalContext(pvd ContextProvider) *OptionalCfg {
+	return &OptionalCfg{pvd: pvd}
+}
+
+// Load populates the provider according to the definition of the dstCfg unless its config does not exist
+func (cfg *OptionalCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates the provider like Load, and loading is canceled once the ctx is done
+func (cfg *OptionalCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	result, err := loadReported(ctx, cfg.pvd, dstCfg)
+	if err != nil && isNotFound(err) {
+		setReport(ctx, fmt.Sprintf("skipped optional %s: not found", describe(unwrap(cfg.pvd))))
+		return nil
+	}
+	if err == nil {
+		setReport(ctx, result)
+	}
+	return err
+}
+
+// FirstOfCfg is a configuration loader which applies the first provider loaded successfully
+type FirstOfCfg struct {
+	pvds []ContextProvider
+}
+
+// FirstOf inits a FirstOfCfg for the pvds,
+// they are tried in order and a failed one leaves no change in the dstCfg
+func FirstOf(pvds ...CfgProvider) *FirstOfCfg {
+	ctxPvds := []ContextProvider{}
+	for _, pvd := range pvds {
+		ctxPvds = append(ctxPvds, Contextual(pvd))
+	}
+	return &FirstOfCfg{pvds: ctxPvds}
+}
+
+// FirstOfContext inits a FirstOfCfg for the pvds like FirstOf, e.g. FirstOfContext(Timeout(...), ...)
+func FirstOfContext(pvds ...ContextProvider) *FirstOfCfg {
+	return &FirstOfCfg{pvds: pvds}
+}
+
+// Load populates the first provider loaded successfully according to the definition of the dstCfg
+func (cfg *FirstOfCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates the first provider like Load, and loading is canceled once the ctx is done
+func (cfg *FirstOfCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	errs := []string{}
+	for _, pvd := range cfg.pvds {
+		result, err := loadOrKeep(ctx, pvd, dstCfg)
+		if err == nil {
+			setReport(ctx, fmt.Sprintf("first of %d providers: %s", len(cfg.pvds), result))
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", describe(pvd), err))
+	}
+	return fmt.Errorf("gocfg: no provider is loaded: %s", strings.Join(errs, "; "))
+}
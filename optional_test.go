@@ -0,0 +1,128 @@
+package gocfg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOptional(t *testing.T) {
+	type config struct {
+		Name   string `json:"name" yaml:"name"`
+		IntVal int    `json:"intVal" yaml:"intVal"`
+	}
+
+	path, cleanup := writeTempFile(t, "app.yaml", "name: local\n")
+	defer cleanup()
+	missingPath := filepath.Join(filepath.Dir(path), "missing.yaml")
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	logger := newRecordLogger()
+	cfg, err := New(&config{}, WithLogger(logger)).
+		Load(JSONStr(`{"name": "default"}`), Optional(YAML(missingPath)), Optional(HTTP(server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "default" {
+		t.Fatalf("key Name not match: expected: default, got: %s", cfg.GrabString("Name"))
+	}
+	if report := logger.find("INFO", "provider loaded")[1].attrs["result"]; report != "skipped optional YAMLCfg("+missingPath+"): not found" {
+		t.Fatalf("report not match: got: %s", report)
+	}
+
+	// other errors are not ignored
+	badPath, cleanupBad := writeTempFile(t, "bad.json", "{")
+	defer cleanupBad()
+	if _, err = New(&config{}).Load(Optional(JSON(badPath))); err == nil {
+		t.Fatal("invalid file should be reported")
+	}
+	missingKey := KeyFromFile(filepath.Join(filepath.Dir(path), "missing.key"))
+	if _, err = New(&config{}).Load(Optional(Encrypted(YAML(path), missingKey))); err == nil {
+		t.Fatal("missing key file should be reported")
+	}
+	if name := describe(Encrypted(YAML(path), missingKey)); name != "EncryptedCfg(YAMLCfg("+path+"))" {
+		t.Fatalf("name not match: got: %s", name)
+	}
+
+	logger = newRecordLogger()
+	cfg, err = New(&config{IntVal: 1}, WithLogger(logger)).Load(FirstOf(YAML(missingPath), JSON(badPath), YAML(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "local" || cfg.GrabInt("IntVal") != 1 {
+		t.Fatalf("config not match: got: %s", cfg.ToString())
+	}
+	if report := logger.find("INFO", "provider loaded")[0].attrs["result"]; report != "first of 3 providers: applied YAMLCfg("+path+")" {
+		t.Fatalf("report not match: got: %s", report)
+	}
+
+	_, err = New(&config{}).Load(FirstOf(YAML(missingPath), JSON(badPath)))
+	if err == nil || !strings.HasPrefix(err.Error(), "gocfg: no provider is loaded: YAMLCfg(") {
+		t.Fatalf("error not match: got: %v", err)
+	}
+}
+
+func TestOptionalContext(t *testing.T) {
+	type config struct {
+		Name string `json:"name" yaml:"name"`
+	}
+
+	path, cleanup := writeTempFile(t, "app.yaml", "name: local\n")
+	defer cleanup()
+	missingPath := filepath.Join(filepath.Dir(path), "missing.yaml")
+
+	// wrappers of context providers are accepted
+	logger := newRecordLogger()
+	cfg, err := New(&config{}, WithLogger(logger)).LoadContext(
+		context.Background(),
+		Contextual(OptionalContext(Retry(Contextual(YAML(missingPath)), 2, time.Millisecond))),
+		Contextual(FirstOfContext(Timeout(Contextual(YAML(missingPath)), time.Second), Timeout(Contextual(YAML(path)), time.Second))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "local" {
+		t.Fatalf("key Name not match: expected: local, got: %s", cfg.GrabString("Name"))
+	}
+	expected := []string{
+		"skipped optional YAMLCfg(" + missingPath + "): not found",
+		"first of 2 providers: applied YAMLCfg(" + path + ")",
+	}
+	for i, log := range logger.find("INFO", "provider loaded") {
+		if log.attrs["result"] != expected[i] {
+			t.Fatalf("report not match: expected: %s, got: %s", expected[i], log.attrs["result"])
+		}
+	}
+
+	// a wrapper shared by concurrent loads reports every load separately
+	optional := Optional(YAML(missingPath))
+	first := FirstOf(YAML(missingPath), YAML(path))
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pvd := CfgProvider(optional)
+			expected := "skipped optional YAMLCfg(" + missingPath + "): not found"
+			if i%2 == 1 {
+				pvd, expected = first, "first of 2 providers: applied YAMLCfg("+path+")"
+			}
+			logger := newRecordLogger()
+			if _, err := New(&config{}, WithLogger(logger)).Load(pvd); err != nil {
+				t.Error(err)
+				return
+			}
+			if report := logger.find("INFO", "provider loaded")[0].attrs["result"]; report != expected {
+				t.Errorf("report not match: expected: %s, got: %s", expected, report)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
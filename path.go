@@ -0,0 +1,104 @@
+package gocfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fieldPath returns the path of a struct field, e.g. StructVal.IntVal
+func fieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", parent, name)
+}
+
+// indexPath returns the path of a slice element, e.g. SliceVal[0]
+func indexPath(parent string, i int) string {
+	if parent == "" {
+		// actually the root should not be an array
+		return fmt.Sprintf("%d", i)
+	}
+	return fmt.Sprintf("%s[%d]", parent, i)
+}
+
+// keyPath returns the path of a map entry, e.g. MapVal[foo],
+// the key is quoted if it contains dots, brackets or quotes, e.g. MapVal["foo.bar"]
+func keyPath(parent, key string) string {
+	if key == "" || strings.ContainsAny(key, `.[]"`) {
+		key = strconv.Quote(key)
+	}
+	if parent == "" {
+		return key
+	}
+	return fmt.Sprintf("%s[%s]", parent, key)
+}
+
+// SplitPath splits a config path into its segments,
+// e.g. MapVal["foo.bar"].SliceVal[0] is split into MapVal, foo.bar, SliceVal and 0
+func SplitPath(path string) ([]string, error) {
+	segments := []string{}
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			if i == 0 || i == len(path)-1 {
+				return nil, fmt.Errorf("gocfg: invalid path %s", path)
+			}
+			i++
+		case '[':
+			end := -1
+			if i+1 < len(path) && path[i+1] == '"' {
+				key, n, err := unquotePrefix(path[i+1:])
+				if err != nil {
+					return nil, fmt.Errorf("gocfg: invalid path %s: %s", path, err)
+				}
+				segments = append(segments, key)
+				end = i + 1 + n
+			} else {
+				closing := strings.IndexByte(path[i:], ']')
+				if closing > 0 {
+					segments = append(segments, path[i+1:i+closing])
+					end = i + closing
+				}
+			}
+			if end < 0 || end >= len(path) || path[end] != ']' {
+				return nil, fmt.Errorf("gocfg: invalid path %s: unclosed bracket", path)
+			}
+			i = end + 1
+		case '"':
+			key, n, err := unquotePrefix(path[i:])
+			if err != nil {
+				return nil, fmt.Errorf("gocfg: invalid path %s: %s", path, err)
+			}
+			segments = append(segments, key)
+			i += n
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, path[i:i+end])
+			i += end
+		}
+	}
+	return segments, nil
+}
+
+// unquotePrefix unquotes the quoted string at the beginning of s,
+// it also returns the length of the quoted string
+func unquotePrefix(s string) (string, int, error) {
+	if s == "" || s[0] != '"' {
+		return "", 0, fmt.Errorf("missing quote")
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err := strconv.Unquote(s[:i+1])
+			return key, i + 1, err
+		}
+	}
+	return "", 0, fmt.Errorf("unclosed quote")
+}
@@ -0,0 +1,45 @@
+package gocfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	inputs := []string{
+		"IntVal",
+		"StructVal.IntVal",
+		"SliceVal[1].StructVal.IntVal",
+		"MapVal[foo].IntVal",
+		`MapVal["foo.bar"][0]`,
+		`MapVal["a\"]"].IntVal`,
+	}
+	outputs := [][]string{
+		{"IntVal"},
+		{"StructVal", "IntVal"},
+		{"SliceVal", "1", "StructVal", "IntVal"},
+		{"MapVal", "foo", "IntVal"},
+		{"MapVal", "foo.bar", "0"},
+		{"MapVal", `a"]`, "IntVal"},
+	}
+
+	for i, input := range inputs {
+		segments, err := SplitPath(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(segments, outputs[i]) {
+			t.Fatalf("segments of %s not match: expected: %v, got: %v", input, outputs[i], segments)
+		}
+	}
+
+	for _, input := range []string{"MapVal[foo", `MapVal["foo]`, ".IntVal"} {
+		if _, err := SplitPath(input); err == nil {
+			t.Fatalf("path %s should be invalid", input)
+		}
+	}
+
+	if keyPath("MapVal", `a"]`) != `MapVal["a\"]"]` {
+		t.Fatalf("key path not match: got: %s", keyPath("MapVal", `a"]`))
+	}
+}
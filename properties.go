@@ -0,0 +1,131 @@
+package gocfg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// PropertiesCfg is a configuration loader for a local Java .properties file
+type PropertiesCfg struct {
+	path string
+}
+
+// Properties inits a PropertiesCfg according to the .properties file in the path,
+// dotted keys are mapped to paths, e.g. StructVal.IntVal=2 is mapped to StructVal.IntVal
+func Properties(path string) *PropertiesCfg {
+	return &PropertiesCfg{path: path}
+}
+
+// Source returns the path of the properties file
+func (cfg *PropertiesCfg) Source() string {
+	return cfg.path
+}
+
+// Load populates .properties file according to the definition of the dstCfg
+func (cfg *PropertiesCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates .properties file like Load with options in the ctx
+func (cfg *PropertiesCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadFile(cfg.path)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, FormatProperties, cfg.path, cfgBytes, dstCfg)
+}
+
+// parseProperties parses lines like `key=value`, `key: value` or `key value`,
+// a line ending with a backslash continues in the next line
+func parseProperties(content string) ([]*kvLine, error) {
+	lines := []*kvLine{}
+	rows := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(rows); i++ {
+		lineNum := i + 1
+		row := strings.TrimLeft(rows[i], " \t\f")
+		if row == "" || row[0] == '#' || row[0] == '!' {
+			continue
+		}
+		for endsWithEscape(row) && i+1 < len(rows) {
+			i++
+			row = row[:len(row)-1] + strings.TrimLeft(rows[i], " \t\f")
+		}
+
+		// the key ends at the first unescaped separator
+		keyEnd := len(row)
+		for j := 0; j < len(row); j++ {
+			if row[j] == '\\' {
+				j++
+			} else if strings.IndexByte("=: \t\f", row[j]) >= 0 {
+				keyEnd = j
+				break
+			}
+		}
+		rest := strings.TrimLeft(row[keyEnd:], " \t\f")
+		if len(rest) > 0 && (rest[0] == '=' || rest[0] == ':') {
+			rest = strings.TrimLeft(rest[1:], " \t\f")
+		}
+
+		key, err := unescapeProperty(row[:keyEnd])
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s", lineNum, err)
+		}
+		val, err := unescapeProperty(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s", lineNum, err)
+		}
+		lines = append(lines, &kvLine{key: key, value: val, line: lineNum})
+	}
+	return lines, nil
+}
+
+// endsWithEscape checks if the row ends with an odd number of backslashes
+func endsWithEscape(row string) bool {
+	count := 0
+	for i := len(row) - 1; i >= 0 && row[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape in %s", s)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape in %s", s)
+			}
+			sb.WriteRune(rune(code))
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
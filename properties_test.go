@@ -0,0 +1,53 @@
+package gocfg
+
+import (
+	"testing"
+)
+
+func TestProperties(t *testing.T) {
+	content := `
+# comment
+! comment
+name = my \
+       app
+intVal:1
+StructVal.IntVal 2
+StructVal.labels.team=infra!
+StructVal.SliceVal[1].name=a\=b
+key\ with\ spaces=ignored
+`
+	path, cleanup := writeTempFile(t, "app.properties", content)
+	defer cleanup()
+
+	cfg, err := New(&iniConfig{}).Load(Properties(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strs := map[string]string{
+		"Name":                       "my app",
+		"StructVal.Labels[team]":     "infra!",
+		"StructVal.SliceVal[1].Name": "a=b",
+	}
+	for key, val := range strs {
+		if cfg.GrabString(key) != val {
+			t.Fatalf("key %s not match: expected: %s, got: %s", key, val, cfg.GrabString(key))
+		}
+	}
+	ints := map[string]int{
+		"IntVal":           1,
+		"StructVal.IntVal": 2,
+	}
+	for key, val := range ints {
+		if cfg.GrabInt(key) != val {
+			t.Fatalf("key %s not match: expected: %d, got: %d", key, val, cfg.GrabInt(key))
+		}
+	}
+
+	path, cleanup = writeTempFile(t, "app.properties", "\nStructVal.IntVal=abc\n")
+	defer cleanup()
+	_, err = New(&iniConfig{}).Load(Properties(path))
+	if err == nil || err.Error() != `gocfg: `+path+`:2 StructVal.IntVal: expected int, got "abc"` {
+		t.Fatalf("error not match: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package gocfg
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kind is the type of an indexed config value
+type Kind int
+
+const (
+	InvalidKind Kind = iota
+	BoolKind
+	IntKind
+	FloatKind
+	StringKind
+	MapKind
+	SliceKind
+	StructKind
+)
+
+var kindNames = map[Kind]string{
+	InvalidKind: "invalid",
+	BoolKind:    "bool",
+	IntKind:     "int",
+	FloatKind:   "float",
+	StringKind:  "string",
+	MapKind:     "map",
+	SliceKind:   "slice",
+	StructKind:  "struct",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return kindNames[InvalidKind]
+}
+
+// Entry is a config value with its path
+type Entry struct {
+	Path  string
+	Kind  Kind
+	Value interface{}
+}
+
+// Query returns entries whose paths match the pattern, a "*" in the pattern matches any one segment,
+// e.g. SliceVal[*].IntVal or MapVal[*].StructVal.*
+// Entries are sorted by their types and then their paths, same as ToString.
+func (c *Cfg) Query(pattern string) ([]*Entry, error) {
+	patternSegs, err := SplitPath(c.comparablePath(pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*Entry{}
+	for _, entry := range c.entries() {
+		segs, err := SplitPath(c.comparablePath(entry.Path))
+		if err != nil || !matchSegments(patternSegs, segs) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	return matched, nil
+}
+
+// Keys returns the entry of the prefix and entries under the prefix, all entries are returned if prefix is empty.
+// Entries are sorted by their types and then their paths, same as ToString.
+func (c *Cfg) Keys(prefix string) []*Entry {
+	matched := []*Entry{}
+	for _, entry := range c.entries() {
+		if hasPathPrefix(c.comparablePath(entry.Path), c.comparablePath(prefix)) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Has checks if there is a config value of the key
+func (c *Cfg) Has(key string) bool {
+	return c.TypeOf(key) != InvalidKind
+}
+
+// TypeOf returns the kind of the config value of the key, it returns InvalidKind if nothing is found.
+func (c *Cfg) TypeOf(key string) Kind {
+	key = c.resolve(key)
+	if _, ok := c.boolVals[key]; ok {
+		return BoolKind
+	} else if _, ok := c.intVals[key]; ok {
+		return IntKind
+	} else if _, ok := c.floatVals[key]; ok {
+		return FloatKind
+	} else if _, ok := c.stringVals[key]; ok {
+		return StringKind
+	} else if _, ok := c.mapVals[key]; ok {
+		return MapKind
+	} else if _, ok := c.sliceVals[key]; ok {
+		return SliceKind
+	} else if _, ok := c.structVals[key]; ok {
+		return StructKind
+	}
+	return InvalidKind
+}
+
+// AllKeys returns paths of all config values, they are sorted by their types and then their paths, same as ToString.
+func (c *Cfg) AllKeys() []string {
+	keys := []string{}
+	for _, entry := range c.entries() {
+		keys = append(keys, entry.Path)
+	}
+	return keys
+}
+
+// Walk calls fn for every config value in the order of ToString, it stops once fn returns an error.
+func (c *Cfg) Walk(fn func(path string, kind Kind, value interface{}) error) error {
+	for _, entry := range c.entries() {
+		if err := fn(entry.Path, entry.Kind, entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entries returns all of the values in the Cfg in the order of ToString
+func (c *Cfg) entries() []*Entry {
+	entries := []*Entry{}
+	appendSorted := func(kind Kind, vals map[string]interface{}) {
+		keys := []string{}
+		for k := range vals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entries = append(entries, &Entry{Path: k, Kind: kind, Value: vals[k]})
+		}
+	}
+
+	boolVals := map[string]interface{}{}
+	for k, v := range c.boolVals {
+		boolVals[k] = v
+	}
+	appendSorted(BoolKind, boolVals)
+
+	intVals := map[string]interface{}{}
+	for k, v := range c.intVals {
+		intVals[k] = v
+	}
+	appendSorted(IntKind, intVals)
+
+	floatVals := map[string]interface{}{}
+	for k, v := range c.floatVals {
+		floatVals[k] = v
+	}
+	appendSorted(FloatKind, floatVals)
+
+	stringVals := map[string]interface{}{}
+	for k, v := range c.stringVals {
+		stringVals[k] = v
+	}
+	appendSorted(StringKind, stringVals)
+
+	appendSorted(MapKind, c.mapVals)
+	appendSorted(SliceKind, c.sliceVals)
+	appendSorted(StructKind, c.structVals)
+	return entries
+}
+
+func matchSegments(patternSegs, segs []string) bool {
+	if len(patternSegs) != len(segs) {
+		return false
+	}
+	for i, patternSeg := range patternSegs {
+		if patternSeg != "*" && patternSeg != segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPathPrefix checks if the path is the prefix or it is under the prefix
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" || path == prefix {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	next := path[len(prefix)]
+	return next == '.' || next == '['
+}
@@ -0,0 +1,161 @@
+package gocfg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	type upstream struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type config struct {
+		Name      string               `json:"name"`
+		Upstreams []*upstream          `json:"upstreams"`
+		Backup    *upstream            `json:"backup"`
+		Zones     map[string]*upstream `json:"zones"`
+	}
+
+	input := `{
+		"name": "app",
+		"upstreams": [
+			{"host": "a.example.com", "port": 80},
+			{"host": "b.example.com", "port": 8080}
+		],
+		"backup": {"host": "c.example.com", "port": 81},
+		"zones": {"east": {"host": "d.example.com", "port": 82}}
+	}`
+	cfg, err := New(&config{}).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("query with wildcards", func(t *testing.T) {
+		inputs := []string{
+			"Upstreams[*].Host",
+			"*.Port",
+			"Zones[*].*",
+			"Upstreams[2].Host",
+		}
+		outputs := [][]*Entry{
+			{
+				{Path: "Upstreams[0].Host", Value: "a.example.com"},
+				{Path: "Upstreams[1].Host", Value: "b.example.com"},
+			},
+			{
+				{Path: "Backup.Port", Value: 81},
+			},
+			{
+				{Path: "Zones[east].Port", Value: 82},
+				{Path: "Zones[east].Host", Value: "d.example.com"},
+			},
+			{},
+		}
+
+		for i, input := range inputs {
+			entries, err := cfg.Query(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEntries(t, input, entries, outputs[i])
+		}
+	})
+
+	t.Run("keys with prefix", func(t *testing.T) {
+		entries := cfg.Keys("Upstreams[1]")
+		checkEntries(t, "Upstreams[1]", entries, []*Entry{
+			{Path: "Upstreams[1].Port", Value: 8080},
+			{Path: "Upstreams[1].Host", Value: "b.example.com"},
+			{Path: "Upstreams[1]", Value: cfg.GrabStruct("Upstreams[1]")},
+		})
+
+		if len(cfg.Keys("Name")) != 1 {
+			t.Fatalf("Name should only match itself: %v", cfg.Keys("Name"))
+		}
+		if len(cfg.Keys("")) != len(cfg.entries()) {
+			t.Fatal("empty prefix should match all")
+		}
+	})
+}
+
+func checkEntries(t *testing.T, input string, entries, expected []*Entry) {
+	if len(entries) != len(expected) {
+		t.Fatalf("%s: entries size not match: expected: %d, got: %d", input, len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Path != expected[i].Path || entry.Value != expected[i].Value {
+			t.Fatalf("%s: entry not match: expected: %s=%v, got: %s=%v", input, expected[i].Path, expected[i].Value, entry.Path, entry.Value)
+		}
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	type config struct {
+		BoolVal   bool           `json:"boolVal"`
+		IntVal    int            `json:"intVal"`
+		MapVal    map[string]int `json:"mapVal"`
+		StructVal *config        `json:"structVal"`
+	}
+
+	input := `{"boolVal": true, "intVal": 1, "mapVal": {"a": 2}, "structVal": {"intVal": 3}}`
+	var icfg ICfg
+	cfg, err := New(&config{}).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	icfg = cfg
+
+	kinds := map[string]Kind{
+		"BoolVal":          BoolKind,
+		"IntVal":           IntKind,
+		"MapVal":           MapKind,
+		"MapVal[a]":        IntKind,
+		"StructVal":        StructKind,
+		"StructVal.IntVal": IntKind,
+		"NotFound":         InvalidKind,
+	}
+	for key, kind := range kinds {
+		if icfg.TypeOf(key) != kind {
+			t.Fatalf("kind of %s not match: expected: %s, got: %s", key, kind, icfg.TypeOf(key))
+		}
+		if icfg.Has(key) != (kind != InvalidKind) {
+			t.Fatalf("key %s existence not match", key)
+		}
+	}
+
+	expectedKeys := []string{
+		"BoolVal",
+		"StructVal.BoolVal",
+		"IntVal",
+		"MapVal[a]",
+		"StructVal.IntVal",
+		"MapVal",
+		"StructVal.MapVal",
+		"",
+		"StructVal",
+	}
+	keys := icfg.AllKeys()
+	if strings.Join(keys, ",") != strings.Join(expectedKeys, ",") {
+		t.Fatalf("keys not match: expected: %v, got: %v", expectedKeys, keys)
+	}
+
+	walked := []string{}
+	err = icfg.Walk(func(path string, kind Kind, value interface{}) error {
+		walked = append(walked, path)
+		if kind != icfg.TypeOf(path) {
+			t.Fatalf("kind of %s not match: expected: %s, got: %s", path, icfg.TypeOf(path), kind)
+		}
+		if path == "MapVal[a]" {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "stop" {
+		t.Fatalf("error of walk not match: %v", err)
+	}
+	if strings.Join(walked, ",") != strings.Join(expectedKeys[:4], ",") {
+		t.Fatalf("walked keys not match: expected: %v, got: %v", expectedKeys[:4], walked)
+	}
+}
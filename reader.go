@@ -0,0 +1,262 @@
+package gocfg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Formats supported by Reader and FS
+const (
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatTOML       = "toml"
+	FormatXML        = "xml"
+	FormatDotEnv     = "dotenv"
+	FormatINI        = "ini"
+	FormatProperties = "properties"
+)
+
+var formatExts = map[string]string{
+	".json":       FormatJSON,
+	".yaml":       FormatYAML,
+	".yml":        FormatYAML,
+	".toml":       FormatTOML,
+	".xml":        FormatXML,
+	".env":        FormatDotEnv,
+	".ini":        FormatINI,
+	".properties": FormatProperties,
+}
+
+// FormatOf returns the format of the file according to its extension, it is empty if the extension is unknown.
+// The .enc extension of encrypted files is skipped, e.g. app.yaml.enc is a yaml file.
+func FormatOf(filePath string) string {
+	filePath = strings.TrimSuffix(strings.ToLower(filePath), ".enc")
+	return formatExts[path.Ext(filePath)]
+}
+
+// ReaderCfg is a configuration loader for an io.Reader
+type ReaderCfg struct {
+	format string
+	r      io.Reader
+}
+
+// Reader inits a ReaderCfg which decodes the content of r in the format, e.g. FormatJSON,
+// r is read to the end when it is loaded, so it can only be loaded once.
+func Reader(format string, r io.Reader) *ReaderCfg {
+	return &ReaderCfg{format: format, r: r}
+}
+
+// Load populates content of the reader according to the definition of the dstCfg
+func (cfg *ReaderCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates content of the reader like Load with options in the ctx
+func (cfg *ReaderCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	cfgBytes, err := ioutil.ReadAll(cfg.r)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, cfg.format, cfg.format, cfgBytes, dstCfg)
+}
+
+// FSCfg is a configuration loader for a file in an fs.FS, e.g. an embed.FS
+type FSCfg struct {
+	fsys fs.FS
+	path string
+}
+
+// FS inits a FSCfg according to the file in the path of fsys, the format is detected by FormatOf
+func FS(fsys fs.FS, path string) *FSCfg {
+	return &FSCfg{fsys: fsys, path: path}
+}
+
+// Source returns the path of the file in the fs
+func (cfg *FSCfg) Source() string {
+	return cfg.path
+}
+
+// Load populates the file according to the definition of the dstCfg
+func (cfg *FSCfg) Load(dstCfg interface{}) error {
+	return cfg.LoadContext(context.Background(), dstCfg)
+}
+
+// LoadContext populates the file like Load with options in the ctx
+func (cfg *FSCfg) LoadContext(ctx context.Context, dstCfg interface{}) error {
+	format := FormatOf(cfg.path)
+	if format == "" {
+		return fmt.Errorf("gocfg: unknown format of %s", cfg.path)
+	}
+
+	cfgBytes, err := fs.ReadFile(cfg.fsys, cfg.path)
+	if err != nil {
+		return err
+	}
+	return decode(ctx, format, cfg.path, cfgBytes, dstCfg)
+}
+
+// decode populates content in the format according to the definition of the dstCfg,
+// name is used in error messages, unknown keys are rejected if the ctx is strict,
+// json, yaml and toml content is migrated first if there are migrations in the ctx,
+// and the content is decrypted before all if there is a decryption key in the ctx
+func decode(ctx context.Context, format, name string, content []byte, dstCfg interface{}) error {
+	if key, ok := decryptionKey(ctx); ok {
+		plaintext, err := decrypt(content, key)
+		if err != nil {
+			return fmt.Errorf("gocfg: %s: %w", name, err)
+		}
+		content = plaintext
+	}
+
+	switch format {
+	case FormatJSON, FormatYAML, FormatTOML, FormatXML:
+		// positions in errors refer to the migrated content if it is migrated, and the name tells it
+		content, name, err := migrateDocument(ctx, format, name, content)
+		if err != nil {
+			return err
+		}
+		aliases := resolveAliases(format, name, content, reflect.TypeOf(dstCfg), keyStrategyOf(ctx))
+		if err = reportDeprecated(ctx, aliases.keys); err != nil {
+			return err
+		}
+		err = decodeDocument(ctx, format, name, aliases.content, dstCfg)
+		if err == nil {
+			return aliases.apply(format, content, dstCfg)
+		}
+		if _, ok := err.(*UnknownKeysError); ok {
+			return err
+		}
+		return parseError(format, name, aliases.content, dstCfg, err, keyStrategyOf(ctx))
+	case FormatDotEnv:
+		return loadDotEnv(ctx, name, string(content), dstCfg)
+	case FormatINI:
+		lines, err := parseINI(string(content))
+		if err != nil {
+			return fmt.Errorf("gocfg: %s:%s", name, err)
+		}
+		return loadPaths(ctx, format, name, lines, dstCfg)
+	case FormatProperties:
+		lines, err := parseProperties(string(content))
+		if err != nil {
+			return fmt.Errorf("gocfg: %s:%s", name, err)
+		}
+		return loadPaths(ctx, format, name, lines, dstCfg)
+	}
+	return fmt.Errorf("gocfg: unknown format %s", format)
+}
+
+// decodeDocument decodes json, yaml, toml or xml content with their decoders,
+// unknown keys are rejected if the ctx is strict
+func decodeDocument(ctx context.Context, format, name string, content []byte, dstCfg interface{}) error {
+	strict := isStrict(ctx)
+	switch format {
+	case FormatJSON:
+		if !strict {
+			return json.Unmarshal(content, dstCfg)
+		}
+		node, err := jsonNode(content)
+		if err != nil {
+			return err
+		}
+		checker := newKeyChecker(name, jsonNaming, keyStrategyOf(ctx))
+		checker.check(node, reflect.TypeOf(dstCfg))
+		if err = unknownKeysErr(checker.keys); err != nil {
+			return err
+		}
+		return json.Unmarshal(content, dstCfg)
+	case FormatYAML:
+		if !strict {
+			return yaml.Unmarshal(content, dstCfg)
+		}
+		node := &yaml.Node{}
+		if err := yaml.Unmarshal(content, node); err != nil {
+			return err
+		}
+		checker := newKeyChecker(name, yamlNaming, keyStrategyOf(ctx))
+		checker.check(node, reflect.TypeOf(dstCfg))
+		if err := unknownKeysErr(checker.keys); err != nil {
+			return err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		dec.KnownFields(true)
+		if err := dec.Decode(dstCfg); err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	case FormatTOML:
+		if _, err := toml.Decode(string(content), dstCfg); err != nil || !strict {
+			return err
+		}
+		node, err := tomlNode(content)
+		if err != nil {
+			return err
+		}
+		checker := newKeyChecker(name, tomlNaming, keyStrategyOf(ctx))
+		checker.check(node, reflect.TypeOf(dstCfg))
+		return unknownKeysErr(checker.keys)
+	case FormatXML:
+		if strict {
+			checker := newKeyChecker(name, xmlNaming, keyStrategyOf(ctx))
+			checker.checkXML(content, reflect.TypeOf(dstCfg))
+			if err := unknownKeysErr(checker.keys); err != nil {
+				return err
+			}
+		}
+		// xml.Unmarshal appends to slices, so slices in the content replace existing ones like other formats
+		restore := resetSlices(reflect.ValueOf(dstCfg))
+		defer restore()
+		return xml.Unmarshal(content, dstCfg)
+	}
+	return fmt.Errorf("gocfg: unknown format %s", format)
+}
+
+// resetSlices empties slices in fields of v,
+// and returns a func which restores slices still empty, which are not set by the content
+func resetSlices(v reflect.Value) func() {
+	restores := []func(){}
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" {
+					walk(v.Field(i))
+				}
+			}
+		case reflect.Slice:
+			if v.Len() == 0 || v.Type().Elem().Kind() == reflect.Uint8 || !v.CanSet() {
+				return
+			}
+			field, old := v, v.Interface()
+			field.Set(reflect.Zero(field.Type()))
+			restores = append(restores, func() {
+				if field.Len() == 0 {
+					field.Set(reflect.ValueOf(old))
+				}
+			})
+		}
+	}
+	walk(v)
+
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gocfg
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReaderAndFS(t *testing.T) {
+	type config struct {
+		Name   string `json:"name" yaml:"name" toml:"name" xml:"name"`
+		IntVal int    `json:"intVal" yaml:"intVal" toml:"intVal" xml:"intVal"`
+	}
+
+	fsys := fstest.MapFS{
+		"defaults/app.json":       {Data: []byte(`{"name": "json", "intVal": 1}`)},
+		"defaults/app.yml":        {Data: []byte("name: yaml\nintVal: 2\n")},
+		"defaults/app.toml":       {Data: []byte("name = \"toml\"\nintVal = 3\n")},
+		"defaults/app.xml":        {Data: []byte("<config><name>xml</name><intVal>4</intVal></config>")},
+		"defaults/.env":           {Data: []byte("NAME=dotenv\nINTVAL=5\n")},
+		"defaults/app.ini":        {Data: []byte("name = ini\nintVal = 6\n")},
+		"defaults/app.properties": {Data: []byte("name=properties\nintVal=7\n")},
+		"defaults/app.txt":        {Data: []byte("")},
+	}
+
+	inputs := []string{
+		"defaults/app.json",
+		"defaults/app.yml",
+		"defaults/app.toml",
+		"defaults/app.xml",
+		"defaults/.env",
+		"defaults/app.ini",
+		"defaults/app.properties",
+	}
+	outputs := []*config{
+		{Name: "json", IntVal: 1},
+		{Name: "yaml", IntVal: 2},
+		{Name: "toml", IntVal: 3},
+		{Name: "xml", IntVal: 4},
+		{Name: "dotenv", IntVal: 5},
+		{Name: "ini", IntVal: 6},
+		{Name: "properties", IntVal: 7},
+	}
+
+	for i, input := range inputs {
+		cfg, err := New(&config{}).Load(FS(fsys, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GrabString("Name") != outputs[i].Name || cfg.GrabInt("IntVal") != outputs[i].IntVal {
+			t.Fatalf("%s not match: expected: %+v, got: %s", input, outputs[i], cfg.ToString())
+		}
+	}
+
+	if _, err := New(&config{}).Load(FS(fsys, "defaults/app.txt")); err == nil {
+		t.Fatal("unknown format should be reported")
+	}
+	if _, err := New(&config{}).Load(FS(fsys, "defaults/missing.json")); err == nil {
+		t.Fatal("missing file should be reported")
+	}
+
+	cfg, err := New(&config{}).Load(Reader(FormatYAML, strings.NewReader("name: reader\nintVal: 8\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Name") != "reader" || cfg.GrabInt("IntVal") != 8 {
+		t.Fatalf("reader not match: got: %s", cfg.ToString())
+	}
+	if _, err := New(&config{}).Load(Reader("hcl", strings.NewReader(""))); err == nil {
+		t.Fatal("unknown format should be reported")
+	}
+}
@@ -0,0 +1,142 @@
+package gocfg
+
+import (
+	"reflect"
+)
+
+var GocfgValSecret = "secret"
+
+// SecretMask replaces secret values in ToString, JSON, YAML, TOML and Dotenv
+var SecretMask = "******"
+
+// isSecret checks if the path or one of its parents is secret
+func (c *Cfg) isSecret(path string) bool {
+	for secretPath := range c.secrets {
+		if secretPath != "" && hasPathPrefix(path, secretPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// masked returns a copy of val whose secret strings are replaced by SecretMask, path is the path of val,
+// secret bools and numbers are replaced by zero values as they can not hold the mask
+func (c *Cfg) masked(val interface{}, path string) interface{} {
+	if len(c.secrets) == 0 || val == nil {
+		return val
+	}
+
+	cloned := reflect.New(reflect.TypeOf(val)).Elem()
+	cloned.Set(cloneValue(reflect.ValueOf(val)))
+	c.mask(cloned, path, c.isSecret(path))
+	return cloned.Interface()
+}
+
+// mask replaces secret strings in v by SecretMask and other secret values by zero values, v must be settable
+func (c *Cfg) mask(v reflect.Value, path string, inSecret bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			c.mask(v.Elem(), path, inSecret)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		c.mask(elem, path, inSecret)
+		v.Set(elem)
+	case reflect.String:
+		if inSecret && v.CanSet() {
+			v.SetString(SecretMask)
+		}
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if inSecret && v.CanSet() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			childPath := indexPath(path, i)
+			c.mask(v.Index(i), childPath, inSecret || c.secrets[childPath])
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, key := range sortedMapKeys(v) {
+			childPath := keyPath(path, key.String())
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			c.mask(elem, childPath, inSecret || c.secrets[childPath])
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+			childPath := fieldPath(path, c.fieldName(field))
+			c.mask(v.Field(i), childPath, inSecret || c.secrets[childPath])
+		}
+	}
+}
+
+// cloneValue deeply copies pointers, slices, arrays, maps and exported struct fields in v
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		cloned := reflect.New(v.Type().Elem())
+		cloned.Elem().Set(cloneValue(v.Elem()))
+		return cloned
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		cloned := reflect.New(v.Type()).Elem()
+		cloned.Set(cloneValue(v.Elem()))
+		return cloned
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cloned := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cloned.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return cloned
+	case reflect.Array:
+		cloned := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cloned.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return cloned
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cloned := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			cloned.SetMapIndex(key, cloneValue(v.MapIndex(key)))
+		}
+		return cloned
+	case reflect.Struct:
+		cloned := reflect.New(v.Type()).Elem()
+		cloned.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				cloned.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return cloned
+	}
+	return v
+}
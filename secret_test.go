@@ -0,0 +1,98 @@
+package gocfg
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSecretMasking(t *testing.T) {
+	type credential struct {
+		User     string `json:"user" yaml:"user"`
+		Password string `json:"password" yaml:"password" cfg:"secret"`
+	}
+	type config struct {
+		Name        string            `json:"name" yaml:"name"`
+		Credentials []*credential     `json:"credentials" yaml:"credentials"`
+		Keys        map[string]string `json:"keys" yaml:"keys" cfg:"secret"`
+		Pin         int               `json:"pin" yaml:"pin" cfg:"secret"`
+		Ratio       float64           `json:"ratio" yaml:"ratio" cfg:"secret"`
+		Enabled     bool              `json:"enabled" yaml:"enabled" cfg:"secret"`
+	}
+
+	input := `{
+		"name": "app",
+		"credentials": [{"user": "admin", "password": "p1"}],
+		"keys": {"ci": "k1"},
+		"pin": 73129,
+		"ratio": 0.731,
+		"enabled": true
+	}`
+	cfg, err := New(&config{}).Load(JSONStr(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("Credentials[0].Password") != "p1" || cfg.GrabString("Keys[ci]") != "k1" {
+		t.Fatal("getters should return secret values")
+	}
+
+	outputs := []string{cfg.ToString()}
+	for _, export := range []func() (string, error){cfg.JSON, cfg.YAML, cfg.TOML, func() (string, error) { return cfg.Dotenv("") }} {
+		out, err := export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, out)
+	}
+	for _, out := range outputs {
+		if strings.Contains(out, "p1") || strings.Contains(out, "k1") || !strings.Contains(out, SecretMask) || !strings.Contains(out, "admin") {
+			t.Fatalf("secrets should be masked:\n%s", out)
+		}
+		if strings.Contains(out, "73129") || strings.Contains(out, "0.731") || strings.Contains(out, "true") {
+			t.Fatalf("non-string secrets should be masked:\n%s", out)
+		}
+	}
+	for _, row := range []string{"Pin:int = " + SecretMask, "Ratio:float = " + SecretMask, "Enabled:bool = " + SecretMask} {
+		if !strings.Contains(outputs[0], row) {
+			t.Fatalf("row %s not found:\n%s", row, outputs[0])
+		}
+	}
+	if cfg.GrabInt("Pin") != 73129 {
+		t.Fatalf("key Pin not match: expected: 73129, got: %d", cfg.GrabInt("Pin"))
+	}
+	if cfg.Template().(*config).Credentials[0].Password != "p1" {
+		t.Fatal("template should not be masked")
+	}
+}
+
+func TestEnvSecretMasking(t *testing.T) {
+	type config struct {
+		Password string `json:"password" cfg:"env,secret"`
+	}
+
+	if err := os.Setenv("PASSWORD", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("PASSWORD")
+
+	cfg, err := New(&config{}).Load(JSONStr(`{"password": "p1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabString("ENV.PASSWORD") != "hunter2" {
+		t.Fatalf("key ENV.PASSWORD not match: expected: hunter2, got: %s", cfg.GrabString("ENV.PASSWORD"))
+	}
+
+	jsonOut, err := cfg.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, out := range []string{cfg.ToString(), jsonOut} {
+		if strings.Contains(out, "hunter2") || strings.Contains(out, "p1") {
+			t.Fatalf("secrets should be masked:\n%s", out)
+		}
+	}
+	if !strings.Contains(cfg.ToString(), "ENV.PASSWORD:string = "+SecretMask) {
+		t.Fatalf("env secret should be masked:\n%s", cfg.ToString())
+	}
+}
@@ -0,0 +1,395 @@
+package gocfg
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type strictCtxKey struct{}
+
+// WithStrict makes Load reject keys which match no field in the template,
+// all unknown keys are reported in an UnknownKeysError
+func WithStrict() Option {
+	return func(c *Cfg) {
+		c.strict = true
+	}
+}
+
+func withStrict(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictCtxKey{}, true)
+}
+
+func isStrict(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictCtxKey{}).(bool)
+	return strict
+}
+
+// UnknownKey is a key in a config which matches no field in the template
+type UnknownKey struct {
+	File       string
+	Line       int
+	Column     int
+	Path       string // path of the key in the template, e.g. Server.nmae, keys of environment variables are kept as they are
+	Suggestion string // path of the most similar field, e.g. Server.Name
+}
+
+func (k *UnknownKey) Error() string {
+	pos := k.File
+	if k.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, k.Line)
+	}
+	if k.Column > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, k.Column)
+	}
+	msg := fmt.Sprintf("%s: unknown key %s", pos, k.Path)
+	if k.Suggestion != "" {
+		msg = fmt.Sprintf("%s, did you mean %s?", msg, k.Suggestion)
+	}
+	return msg
+}
+
+// UnknownKeysError is returned in strict mode if there are unknown keys in a config
+type UnknownKeysError struct {
+	Keys []*UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	msgs := []string{}
+	for _, key := range e.Keys {
+		msgs = append(msgs, key.Error())
+	}
+	return fmt.Sprintf("gocfg: unknown keys: %s", strings.Join(msgs, "; "))
+}
+
+// unknownKeysErr returns nil if there is no unknown key
+func unknownKeysErr(keys []*UnknownKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return &UnknownKeysError{Keys: keys}
+}
+
+// keyNaming describes how a decoder matches keys with struct fields
+type keyNaming struct {
+	tag        string
+	lowerCase  bool // fields without names in tags are named in lower case
+	foldCase   bool // keys are matched case-insensitively
+	autoInline bool // anonymous struct fields without names in tags are inlined
+}
+
+var (
+	jsonNaming = &keyNaming{tag: "json", foldCase: true, autoInline: true}
+	yamlNaming = &keyNaming{tag: "yaml", lowerCase: true}
+	tomlNaming = &keyNaming{tag: "toml", foldCase: true, autoInline: true}
+	xmlNaming  = &keyNaming{tag: "xml", autoInline: true}
+)
+
+// namedField is a struct field named by a keyNaming, its type is nil if keys in it can not be checked,
+// aliases of a field are also namedFields named by the aliases
+type namedField struct {
+	name      string
+	goName    string
+	typ       reflect.Type
+	alias     bool
+	canonical string
+	field     reflect.StructField
+	inlinedBy []reflect.StructField // fields of structs the field is inlined from, the outermost first
+}
+
+// path returns the path of the field in the template in the parent path,
+// fields are named by the strategy and structs the field is inlined from are included
+func (f *namedField) path(parent string, strategy KeyStrategy) string {
+	for _, field := range f.inlinedBy {
+		parent = fieldPath(parent, strategy.fieldName(field))
+	}
+	return fieldPath(parent, strategy.fieldName(f.field))
+}
+
+// fields returns fields of the struct type t and inlined fields are flattened,
+// open is true if the struct accepts any key, e.g. it has an inlined map
+func (n *keyNaming) fields(t reflect.Type) (fields []*namedField, open bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported
+			continue
+		}
+		tag := field.Tag.Get(n.tag)
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name, flags := parts[0], " "+strings.Join(parts[1:], " ")+" "
+
+		if n == xmlNaming {
+			if field.Name == "XMLName" ||
+				strings.Contains(flags, " attr ") ||
+				strings.Contains(flags, " chardata ") ||
+				strings.Contains(flags, " comment ") {
+				continue
+			}
+			if strings.Contains(flags, " innerxml ") || strings.Contains(flags, " any ") {
+				open = true
+				continue
+			}
+		}
+
+		if strings.Contains(flags, " inline ") || n.autoInline && field.Anonymous && name == "" {
+			fieldType := derefType(field.Type)
+			switch fieldType.Kind() {
+			case reflect.Struct:
+				inlined, inlinedOpen := n.fields(fieldType)
+				for _, inlinedField := range inlined {
+					inlinedField.inlinedBy = append([]reflect.StructField{field}, inlinedField.inlinedBy...)
+				}
+				fields = append(fields, inlined...)
+				open = open || inlinedOpen
+				continue
+			case reflect.Map:
+				open = true
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+			if n.lowerCase {
+				name = strings.ToLower(name)
+			}
+		}
+		fieldType := field.Type
+		if n == xmlNaming && strings.Contains(name, ">") {
+			name, fieldType = strings.Split(name, ">")[0], nil
+		}
+		fields = append(fields, &namedField{name: name, goName: field.Name, typ: fieldType, canonical: name, field: field})
+		for _, alias := range fieldAliases(field) {
+			fields = append(fields, &namedField{name: alias, goName: field.Name, typ: fieldType, alias: true, canonical: name, field: field})
+		}
+	}
+	return fields, open
+}
+
+// lookup returns the type of the value named by the key in a value of the type t and the Go name of the matched field,
+// or the key for maps, ok is false if the key matches nothing, and the type is nil if keys in the value can not be checked
+func (n *keyNaming) lookup(t reflect.Type, key string) (childType reflect.Type, goName string, ok bool) {
+	t = derefType(t)
+	if isOpaque(t) {
+		return nil, key, true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if field := n.field(t, key); field != nil {
+			return field.typ, field.goName, true
+		}
+		_, open := n.fields(t)
+		return nil, key, open
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return t.Elem(), key, true
+	}
+	// mismatched values are reported by decoders
+	return nil, key, true
+}
+
+// field returns the field named by the key in the struct type t, aliases are matched case-insensitively,
+// it is nil if t is not a struct or nothing is matched
+func (n *keyNaming) field(t reflect.Type, key string) *namedField {
+	if t = derefType(t); t.Kind() != reflect.Struct || isOpaque(t) {
+		return nil
+	}
+	fields, _ := n.fields(t)
+	for _, field := range fields {
+		if field.name == key || (n.foldCase || field.alias) && strings.EqualFold(field.name, key) {
+			return field
+		}
+	}
+	return nil
+}
+
+// fieldNames returns names of fields in the type t, aliases are excluded
+func (n *keyNaming) fieldNames(t reflect.Type) []string {
+	names := []string{}
+	if t = derefType(t); t.Kind() != reflect.Struct {
+		return names
+	}
+	fields, _ := n.fields(t)
+	for _, field := range fields {
+		if !field.alias {
+			names = append(names, field.name)
+		}
+	}
+	return names
+}
+
+var unmarshalerTypes = []reflect.Type{
+	reflect.TypeOf((*json.Unmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*toml.Unmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem(),
+}
+
+// isOpaque checks if values of the type t are decoded by themselves, so keys in them can not be checked
+func isOpaque(t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return true
+	}
+	for _, unmarshaler := range unmarshalerTypes {
+		if t.Implements(unmarshaler) || reflect.PtrTo(t).Implements(unmarshaler) {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// keyChecker collects unknown keys in a config named name
+type keyChecker struct {
+	name   string
+	walker *nodeWalker
+	keys   []*UnknownKey
+}
+
+func newKeyChecker(name string, naming *keyNaming, strategy KeyStrategy) *keyChecker {
+	return &keyChecker{name: name, walker: &nodeWalker{naming: naming, strategy: strategy}, keys: []*UnknownKey{}}
+}
+
+// visit records the key of the value n if it is unknown, only the outermost unknown key is reported
+func (kc *keyChecker) visit(n *docNode) bool {
+	if !n.unknown {
+		return true
+	}
+	unknownKey := &UnknownKey{File: kc.name, Path: n.path}
+	if n.keyNode != nil {
+		unknownKey.Line, unknownKey.Column = n.keyNode.Line, n.keyNode.Column
+		if similar := suggest(n.keyNode.Value, kc.walker.naming.fieldNames(n.parent)); similar != "" {
+			unknownKey.Suggestion = kc.walker.naming.field(n.parent, similar).path(n.parentPath, kc.walker.strategy)
+		}
+	}
+	kc.keys = append(kc.keys, unknownKey)
+	return false
+}
+
+// check checks keys in the node against the type t
+func (kc *keyChecker) check(node *yaml.Node, t reflect.Type) {
+	kc.walker.walk(rootNode(node, t), kc.visit)
+}
+
+// checkXML checks elements in the xml content against the type t
+func (kc *keyChecker) checkXML(content []byte, t reflect.Type) {
+	if _, err := kc.walker.walkXML(content, t, kc.visit); err != nil {
+		// invalid content is reported by the decoder
+		kc.keys = []*UnknownKey{}
+	}
+}
+
+// tomlTableType returns the element type if t is a slice for arrays of tables
+func tomlTableType(t reflect.Type) reflect.Type {
+	for kind := derefType(t).Kind(); (kind == reflect.Slice || kind == reflect.Array) && !isOpaque(derefType(t)); kind = derefType(t).Kind() {
+		t = derefType(t).Elem()
+	}
+	return t
+}
+
+// position returns the line and the column of the offset in the content, both start from 1
+func position(content []byte, offset int64) (int, int) {
+	before := content[:offset]
+	return bytes.Count(before, []byte("\n")) + 1, int(offset) - bytes.LastIndexByte(before, '\n')
+}
+
+// knownPaths returns paths of all fields in the type t named by the strategy,
+// maps and slices are not expanded
+func knownPaths(t reflect.Type, strategy KeyStrategy) []string {
+	paths := []string{}
+	var walk func(t reflect.Type, path string, seen map[reflect.Type]bool)
+	walk = func(t reflect.Type, path string, seen map[reflect.Type]bool) {
+		t = derefType(t)
+		if t.Kind() != reflect.Struct || isOpaque(t) || seen[t] {
+			if path != "" {
+				paths = append(paths, path)
+			}
+			return
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			walk(field.Type, fieldPath(path, strategy.fieldName(field)), seen)
+		}
+	}
+	walk(t, "", map[reflect.Type]bool{})
+	return paths
+}
+
+// suggest returns the candidate most similar to the key, it is empty if none is similar enough
+func suggest(key string, candidates []string) string {
+	maxDist := len(key) / 3
+	if maxDist < 1 {
+		maxDist = 1
+	}
+
+	best, bestDist := "", maxDist+1
+	for _, candidate := range candidates {
+		if dist := editDistance(strings.ToLower(key), strings.ToLower(candidate)); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// it counts insertions, deletions, substitutions and transpositions of adjacent characters
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	dists := make([][]int, len(ra)+1)
+	for i := range dists {
+		dists[i] = make([]int, len(rb)+1)
+		dists[i][0] = i
+	}
+	for j := range dists[0] {
+		dists[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			dist := dists[i-1][j] + 1
+			if d := dists[i][j-1] + 1; d < dist {
+				dist = d
+			}
+			if d := dists[i-1][j-1] + cost; d < dist {
+				dist = d
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if d := dists[i-2][j-2] + 1; d < dist {
+					dist = d
+				}
+			}
+			dists[i][j] = dist
+		}
+	}
+	return dists[len(ra)][len(rb)]
+}
@@ -0,0 +1,198 @@
+package gocfg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type strictItem struct {
+	Name string `json:"name" yaml:"name" toml:"name" xml:"name"`
+}
+
+type strictConfig struct {
+	IntVal    int               `json:"intVal" yaml:"intVal" toml:"intVal" xml:"intVal"`
+	StructVal *strictItem       `json:"structVal" yaml:"structVal" toml:"structVal" xml:"structVal"`
+	SliceVal  []*strictItem     `json:"sliceVal" yaml:"sliceVal" toml:"sliceVal" xml:"sliceVal"`
+	MapVal    map[string]string `json:"mapVal" yaml:"mapVal" toml:"mapVal" xml:"-"`
+	AnyVal    interface{}       `json:"anyVal" yaml:"anyVal" toml:"anyVal" xml:"-"`
+}
+
+func TestStrict(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pvd      CfgProvider
+		expected []string
+	}{
+		{
+			name: "yaml",
+			pvd: YAMLStr(strings.Join([]string{
+				"intval: 1",
+				"structVal:",
+				"  nmae: a",
+				"sliceVal:",
+				"  - name: b",
+				"  - names: c",
+				"mapVal:",
+				"  anything: d",
+				"anyVal:",
+				"  anything: e",
+				"unrelated: f",
+			}, "\n")),
+			expected: []string{
+				"yaml:1:1: unknown key intval, did you mean IntVal?",
+				"yaml:3:3: unknown key StructVal.nmae, did you mean StructVal.Name?",
+				"yaml:6:5: unknown key SliceVal[1].names, did you mean SliceVal[1].Name?",
+				"yaml:11:1: unknown key unrelated",
+			},
+		},
+		{
+			name: "json",
+			pvd: JSONStr(strings.Join([]string{
+				"{",
+				`  "IntVal": 1,`,
+				`  "structVal": {"nmae": "a"},`,
+				`  "mapVal": {"anything": "b"},`,
+				`  "unrelated": "c"`,
+				"}",
+			}, "\n")),
+			expected: []string{
+				"json:3:17: unknown key StructVal.nmae, did you mean StructVal.Name?",
+				"json:5:3: unknown key unrelated",
+			},
+		},
+		{
+			name: "xml",
+			pvd: XMLStr(strings.Join([]string{
+				"<config>",
+				"  <intVal>1</intVal>",
+				"  <structVal><nmae>a</nmae></structVal>",
+				"  <sliceVal><name>b</name></sliceVal>",
+				"  <sliceVal><names>c</names></sliceVal>",
+				"</config>",
+			}, "\n")),
+			expected: []string{
+				"xml:3:14: unknown key StructVal.nmae, did you mean StructVal.Name?",
+				"xml:5:13: unknown key SliceVal[1].names, did you mean SliceVal[1].Name?",
+			},
+		},
+		{
+			name: "toml",
+			pvd: Reader(FormatTOML, strings.NewReader(strings.Join([]string{
+				"intVal = 1",
+				"[structVal]",
+				"nmae = 'a'",
+				"[[sliceVal]]",
+				"names = 'b'",
+				"[unrelated]",
+				"key = 'c'",
+			}, "\n"))),
+			expected: []string{
+				"toml:3:1: unknown key StructVal.nmae, did you mean StructVal.Name?",
+				"toml:5:1: unknown key SliceVal[0].names, did you mean SliceVal[0].Name?",
+				"toml:6:1: unknown key unrelated",
+			},
+		},
+		{
+			name: "dotenv",
+			pvd:  DotEnvStr("INTVAL=1\nSTRUCTVAL_NMAE=a\n"),
+			expected: []string{
+				"dotenv:2: unknown key STRUCTVAL_NMAE, did you mean STRUCTVAL_NAME?",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := New(&strictConfig{}, WithStrict()).Load(tc.pvd)
+		unknownKeysErr := &UnknownKeysError{}
+		if !errors.As(err, &unknownKeysErr) {
+			t.Fatalf("%s: error not match: got: %v", tc.name, err)
+		}
+		if len(unknownKeysErr.Keys) != len(tc.expected) {
+			t.Fatalf("%s: unknown keys not match: expected: %v, got: %s", tc.name, tc.expected, err)
+		}
+		for i, key := range unknownKeysErr.Keys {
+			if key.Error() != tc.expected[i] {
+				t.Fatalf("%s: unknown key not match: expected: %s, got: %s", tc.name, tc.expected[i], key.Error())
+			}
+		}
+	}
+
+	// unknown keys are ignored if it is not strict
+	cfg, err := New(&strictConfig{}).Load(YAMLStr("intval: 1\nintVal: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrabInt("IntVal") != 2 {
+		t.Fatalf("key IntVal not match: expected: 2, got: %d", cfg.GrabInt("IntVal"))
+	}
+
+	// strict mode is passed through wrappers
+	_, err = New(&strictConfig{}, WithStrict()).Load(Optional(YAMLStr("intval: 1\n")))
+	if err == nil || err.Error() != "gocfg: unknown keys: yaml:1:1: unknown key intval, did you mean IntVal?" {
+		t.Fatalf("error not match: got: %v", err)
+	}
+}
+
+func TestStrictPositions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pvd      CfgProvider
+		expected string
+	}{
+		{
+			// yaml does not accept the escaped slash, so keys are positioned by the json decoder
+			name:     "json escapes",
+			pvd:      JSONStr("{\n  \"structVal\": {\"name\": \"a\\/b\", \"nmae\": 1}\n}"),
+			expected: "json:2:33: unknown key StructVal.nmae, did you mean StructVal.Name?",
+		},
+		{
+			name: "toml",
+			pvd: Reader(FormatTOML, strings.NewReader(strings.Join([]string{
+				"intVal = 1",
+				`mapVal = { a = "}" }`,
+				`anyVal = """`,
+				"nmae = 1",
+				`"""`,
+				"sliceVal = [",
+				"  { name = 'a' }, # ]",
+				"]",
+				"structVal.name = 'b'",
+				"  structVal . 'nmae' = 'c'",
+			}, "\n"))),
+			expected: "toml:10:3: unknown key StructVal.nmae, did you mean StructVal.Name?",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := New(&strictConfig{}, WithStrict()).Load(tc.pvd)
+		unknownKeysErr := &UnknownKeysError{}
+		if !errors.As(err, &unknownKeysErr) || len(unknownKeysErr.Keys) != 1 {
+			t.Fatalf("%s: error not match: got: %v", tc.name, err)
+		}
+		if unknownKeysErr.Keys[0].Error() != tc.expected {
+			t.Fatalf("%s: unknown key not match: expected: %s, got: %s", tc.name, tc.expected, unknownKeysErr.Keys[0])
+		}
+	}
+
+	// invalid json is reported instead of being checked by another decoder
+	_, err := New(&strictConfig{}, WithStrict()).Load(JSONStr("{\"intVal\": 1,}"))
+	parseErr := &ParseError{}
+	if !errors.As(err, &parseErr) || parseErr.Line != 1 || parseErr.Column != 14 {
+		t.Fatalf("error not match: got: %v", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"Name", "Port", "Timeout"}
+	for key, expected := range map[string]string{
+		"name":    "Name",
+		"prot":    "Port",
+		"timout":  "Timeout",
+		"address": "",
+		"x":       "",
+	} {
+		if similar := suggest(key, candidates); similar != expected {
+			t.Fatalf("suggestion of %s not match: expected: %s, got: %s", key, expected, similar)
+		}
+	}
+}
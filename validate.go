@@ -0,0 +1,294 @@
+package gocfg
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GocfgValidateTag is the tag of rules of a field separated by commas, e.g. `validate:"min=1,max=65535"`.
+// Rules are applied to empty strings and zero values too, e.g. "" fails min=3 and url,
+// put omitempty before rules to skip them for empty values, e.g. `validate:"omitempty,url"`.
+// Rules except nonempty are not applied to nil pointers.
+var GocfgValidateTag = "validate"
+
+// FieldError is an error found in the config value of the Path
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		// the root of the config
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError contains all of the errors found when validating a config
+type ValidationError struct {
+	Errs []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := []string{}
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("gocfg: invalid config: %s", strings.Join(msgs, "; "))
+}
+
+// Validator can be implemented by a config struct to check rules across its fields,
+// e.g. a TLS cert and key must both be set
+type Validator interface {
+	Validate() error
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+// parseRules parses a tag like `min=1,max=65535`,
+// regex must be the last rule because its pattern may contain commas
+func parseRules(tagValue string) []*rule {
+	rules := []*rule{}
+	for tagValue != "" {
+		part := tagValue
+		if strings.HasPrefix(tagValue, "regex=") {
+			tagValue = ""
+		} else if i := strings.Index(tagValue, ","); i >= 0 {
+			part, tagValue = tagValue[:i], tagValue[i+1:]
+		} else {
+			tagValue = ""
+		}
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		nameParam := strings.SplitN(part, "=", 2)
+		r := &rule{name: nameParam[0]}
+		if len(nameParam) == 2 {
+			r.param = nameParam[1]
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// validate checks every field of the cfgObj according to its validate tag
+func (c *Cfg) validate(cfgObj interface{}) error {
+	errs := []*FieldError{}
+	c.validateValue(reflect.ValueOf(cfgObj), "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errs: errs}
+	}
+	return nil
+}
+
+func (c *Cfg) validateValue(v reflect.Value, path string, errs *[]*FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.validateValue(v.Index(i), indexPath(path, i), errs)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, key := range sortedMapKeys(v) {
+			c.validateValue(v.MapIndex(key), keyPath(path, key.String()), errs)
+		}
+	case reflect.Struct:
+		if err := callValidator(v); err != nil {
+			*errs = append(*errs, &FieldError{Path: path, Err: err})
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				// unexported
+				continue
+			}
+			childPath := fieldPath(path, c.fieldName(field))
+
+			for _, r := range parseRules(field.Tag.Get(GocfgValidateTag)) {
+				if r.name == "omitempty" {
+					if isEmptyValue(v.Field(i)) {
+						break
+					}
+					continue
+				}
+				if err := checkRule(v.Field(i), r); err != nil {
+					*errs = append(*errs, &FieldError{Path: childPath, Err: err})
+					break
+				}
+			}
+			c.validateValue(v.Field(i), childPath, errs)
+		}
+	}
+}
+
+// callValidator calls Validate if the value or its pointer implements Validator
+func callValidator(v reflect.Value) error {
+	if v.CanInterface() {
+		if validator, ok := v.Interface().(Validator); ok {
+			return validator.Validate()
+		}
+	}
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			return validator.Validate()
+		}
+	}
+	return nil
+}
+
+// isEmptyValue checks if v is nil or the zero value
+func isEmptyValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	return v.IsZero()
+}
+
+func checkRule(v reflect.Value, r *rule) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if r.name == "nonempty" {
+				return fmt.Errorf("must not be empty")
+			}
+			// other rules are not applied to absent values
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch r.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(r.param, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rule %s=%s", r.name, r.param)
+		}
+		val, isLen, err := measure(v)
+		if err != nil {
+			return err
+		}
+
+		if r.name == "min" && val < limit {
+			if isLen {
+				return fmt.Errorf("length must be >= %s", r.param)
+			}
+			return fmt.Errorf("must be >= %s", r.param)
+		} else if r.name == "max" && val > limit {
+			if isLen {
+				return fmt.Errorf("length must be <= %s", r.param)
+			}
+			return fmt.Errorf("must be <= %s", r.param)
+		}
+	case "len":
+		size, err := strconv.Atoi(r.param)
+		if err != nil {
+			return fmt.Errorf("invalid rule len=%s", r.param)
+		}
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			if v.Len() != size {
+				return fmt.Errorf("length must be %d", size)
+			}
+		default:
+			return fmt.Errorf("len is not supported by kind %s", v.Kind())
+		}
+	case "nonempty":
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			if v.Len() == 0 {
+				return fmt.Errorf("must not be empty")
+			}
+		default:
+			if v.IsZero() {
+				return fmt.Errorf("must not be empty")
+			}
+		}
+	case "oneof":
+		val := fmt.Sprintf("%v", v.Interface())
+		options := strings.Split(r.param, "|")
+		for _, option := range options {
+			if val == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+	case "regex":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("regex is not supported by kind %s", v.Kind())
+		}
+		re, err := regexp.Compile(r.param)
+		if err != nil {
+			return fmt.Errorf("invalid rule regex=%s: %s", r.param, err)
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("must match %s", r.param)
+		}
+	case "url":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("url is not supported by kind %s", v.Kind())
+		}
+		u, err := url.Parse(v.String())
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("must be a valid url")
+		}
+	case "hostport":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("hostport is not supported by kind %s", v.Kind())
+		}
+		_, port, err := net.SplitHostPort(v.String())
+		if err != nil {
+			return fmt.Errorf("must be in the form of host:port")
+		}
+		if portNum, err := strconv.Atoi(port); err != nil || portNum < 0 || portNum > 65535 {
+			return fmt.Errorf("must have a valid port")
+		}
+	default:
+		return fmt.Errorf("unknown rule %s", r.name)
+	}
+
+	return nil
+}
+
+// measure returns the number for numeric kinds or the length for the others,
+// the second returned value is true if it is a length
+func measure(v reflect.Value) (float64, bool, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, nil
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, nil
+	}
+	return 0, false, fmt.Errorf("kind %s can not be measured", v.Kind())
+}
@@ -0,0 +1,159 @@
+package gocfg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	type config struct {
+		IntVal    int       `json:"intVal" validate:"min=1,max=65535"`
+		LogLevel  string    `json:"logLevel" validate:"omitempty,oneof=debug|info|warn"`
+		Name      string    `json:"name" validate:"nonempty,regex=^[a-z]{1,8}$"`
+		Code      string    `json:"code" validate:"omitempty,len=2"`
+		URL       string    `json:"url" validate:"omitempty,url"`
+		Addr      string    `json:"addr" validate:"omitempty,hostport"`
+		SliceVal  []*config `json:"sliceVal"`
+		StructVal *config   `json:"structVal"`
+	}
+
+	t.Run("valid config", func(t *testing.T) {
+		input := `{
+			"intVal": 80,
+			"logLevel": "info",
+			"name": "app",
+			"code": "cn",
+			"url": "https://example.com/path",
+			"addr": "localhost:8080",
+			"sliceVal": [
+				{"intVal": 1, "logLevel": "debug", "name": "a"}
+			]
+		}`
+		_, err := New(&config{}).Load(JSONStr(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		input := `{
+			"intVal": 70000,
+			"logLevel": "error",
+			"name": "App",
+			"code": "c",
+			"url": "example",
+			"addr": "localhost",
+			"sliceVal": [
+				{"intVal": 1, "logLevel": "debug", "name": "a"},
+				{"intVal": 0, "logLevel": "debug", "name": "b"}
+			],
+			"structVal": {"intVal": 2, "logLevel": "warn"}
+		}`
+		_, err := New(&config{}).Load(JSONStr(input))
+		if err == nil {
+			t.Fatal("error should be returned")
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("error should be a ValidationError: %s", err)
+		}
+
+		expectedErrs := []string{
+			"IntVal: must be <= 65535",
+			"LogLevel: must be one of debug, info, warn",
+			"Name: must match ^[a-z]{1,8}$",
+			"Code: length must be 2",
+			"URL: must be a valid url",
+			"Addr: must be in the form of host:port",
+			"SliceVal[1].IntVal: must be >= 1",
+			"StructVal.Name: must not be empty",
+		}
+		msgs := []string{}
+		for _, fieldErr := range validationErr.Errs {
+			msgs = append(msgs, fieldErr.Error())
+		}
+		if strings.Join(msgs, "\n") != strings.Join(expectedErrs, "\n") {
+			t.Fatalf("errors not match: expected:\n%s\ngot:\n%s", strings.Join(expectedErrs, "\n"), strings.Join(msgs, "\n"))
+		}
+	})
+
+	t.Run("empty strings", func(t *testing.T) {
+		type emptyConfig struct {
+			Name     string  `json:"name" validate:"min=3"`
+			URL      string  `json:"url" validate:"url"`
+			Optional string  `json:"optional" validate:"omitempty,min=3,url"`
+			Absent   *string `json:"absent" validate:"min=3"`
+		}
+		_, err := New(&emptyConfig{}).Load(JSONStr(`{"name": "", "url": "", "optional": ""}`))
+		expected := "gocfg: invalid config: Name: length must be >= 3; URL: must be a valid url"
+		if err == nil || err.Error() != expected {
+			t.Fatalf("error not match: expected: %s, got: %v", expected, err)
+		}
+
+		_, err = New(&emptyConfig{}).Load(JSONStr(`{"name": "app", "url": "https://example.com", "optional": "ab"}`))
+		expected = "gocfg: invalid config: Optional: length must be >= 3"
+		if err == nil || err.Error() != expected {
+			t.Fatalf("error not match: expected: %s, got: %v", expected, err)
+		}
+	})
+}
+
+type tlsConfig struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+func (cfg *tlsConfig) Validate() error {
+	if (cfg.Cert == "") != (cfg.Key == "") {
+		return errors.New("cert and key must be set together")
+	}
+	return nil
+}
+
+type serverConfig struct {
+	Name    string       `json:"name"`
+	TLS     *tlsConfig   `json:"tls"`
+	Servers []*tlsConfig `json:"servers"`
+}
+
+func (cfg serverConfig) Validate() error {
+	if cfg.Name == "forbidden" {
+		return errors.New("name is forbidden")
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	input := `{
+		"name": "forbidden",
+		"tls": {"cert": "cert.pem"},
+		"servers": [
+			{"cert": "cert.pem", "key": "key.pem"},
+			{"key": "key.pem"}
+		]
+	}`
+	_, err := New(&serverConfig{}).Load(JSONStr(input))
+	if err == nil {
+		t.Fatal("error should be returned")
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error should be a ValidationError: %s", err)
+	}
+
+	expectedErrs := []string{
+		"name is forbidden",
+		"TLS: cert and key must be set together",
+		"Servers[1]: cert and key must be set together",
+	}
+	msgs := []string{}
+	for _, fieldErr := range validationErr.Errs {
+		msgs = append(msgs, fieldErr.Error())
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expectedErrs, "\n") {
+		t.Fatalf("errors not match: expected:\n%s\ngot:\n%s", strings.Join(expectedErrs, "\n"), strings.Join(msgs, "\n"))
+	}
+}
@@ -0,0 +1,312 @@
+package gocfg
+
+import (
+	"bytes"
+	"encoding/xml"
+	yaml "gopkg.in/yaml.v3"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// docNode is a value in a config visited by a nodeWalker
+type docNode struct {
+	node       *yaml.Node   // the value, it is nil for xml elements
+	keyNode    *yaml.Node   // the key of the value, it is nil for the root and elements of sequences
+	typ        reflect.Type // type of the value in the template, it is nil if the value can not be checked
+	parent     reflect.Type // type of the value containing the value
+	field      *namedField  // field named by the key, it is nil for map entries and unknown keys
+	unknown    bool         // the key matches nothing in the template
+	shadowed   bool         // the key is an alias and its field is also named by its name in the same mapping
+	docPath    string       // path of keys in the config, e.g. servers[0].bindPort
+	docKeys    []string     // keys and indexes in the docPath
+	parentPath string       // path of the parent value in the template
+	path       string       // path of the value in the template, e.g. Servers[0].Port
+}
+
+// nodeWalker walks values in configs against the template,
+// it is shared by checking unknown keys, resolving aliases and finding type errors
+type nodeWalker struct {
+	naming   *keyNaming
+	strategy KeyStrategy
+}
+
+// namingOf returns how the decoder of the format matches keys with struct fields
+func namingOf(format string) *keyNaming {
+	switch format {
+	case FormatJSON:
+		return jsonNaming
+	case FormatTOML:
+		return tomlNaming
+	case FormatXML:
+		return xmlNaming
+	}
+	return yamlNaming
+}
+
+// documentNode parses the json, yaml or toml content as a yaml node
+func documentNode(format string, content []byte) (*yaml.Node, error) {
+	switch format {
+	case FormatJSON:
+		return jsonNode(content)
+	case FormatTOML:
+		return tomlNode(content)
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(content, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// rootNode returns the docNode of the node decoded to the type t
+func rootNode(node *yaml.Node, t reflect.Type) *docNode {
+	return &docNode{node: node, typ: t, docKeys: []string{}}
+}
+
+// childNode returns the docNode of the value named by the key in the value n of the type t
+func (w *nodeWalker) childNode(n *docNode, t reflect.Type, key string) *docNode {
+	child := &docNode{
+		parent:     t,
+		docKeys:    append(append([]string{}, n.docKeys...), key),
+		parentPath: n.path,
+	}
+	child.typ, _, child.unknown = w.naming.lookup(t, key)
+	child.unknown = !child.unknown
+	if t.Kind() == reflect.Map {
+		child.docPath, child.path = keyPath(n.docPath, key), keyPath(n.path, key)
+		return child
+	}
+
+	child.docPath, child.path = fieldPath(n.docPath, key), fieldPath(n.path, key)
+	if child.field = w.naming.field(t, key); child.field != nil {
+		child.path = child.field.path(n.path, w.strategy)
+	}
+	return child
+}
+
+// walk calls visit for the value n and values in it in the order of the config,
+// values in a value are not walked if visit returns false for it
+func (w *nodeWalker) walk(n *docNode, visit func(n *docNode) bool) {
+	if n.node == nil {
+		return
+	}
+	switch n.node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.node.Content {
+			content := *n
+			content.node = child
+			w.walk(&content, visit)
+		}
+		return
+	case yaml.AliasNode:
+		target := *n
+		target.node = n.node.Alias
+		w.walk(&target, visit)
+		return
+	}
+
+	if !visit(n) || n.typ == nil {
+		return
+	}
+	t := derefType(n.typ)
+	if isOpaque(t) {
+		return
+	}
+	switch n.node.Kind {
+	case yaml.MappingNode:
+		w.walkMapping(n, n.node, t, visit)
+	case yaml.SequenceNode:
+		if kind := t.Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return
+		}
+		for i, item := range n.node.Content {
+			child := &docNode{
+				node:       item,
+				typ:        t.Elem(),
+				parent:     t,
+				docPath:    indexPath(n.docPath, i),
+				docKeys:    append(append([]string{}, n.docKeys...), strconv.Itoa(i)),
+				parentPath: n.path,
+				path:       indexPath(n.path, i),
+			}
+			w.walk(child, visit)
+		}
+	}
+}
+
+// walkMapping visits values in the mapping of the value n of the type t, merged mappings are included
+func (w *nodeWalker) walkMapping(n *docNode, mapping *yaml.Node, t reflect.Type, visit func(n *docNode) bool) {
+	named := map[string]bool{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if field := w.naming.field(t, mapping.Content[i].Value); field != nil && !field.alias {
+			named[field.goName] = true
+		}
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keyNode, valNode := mapping.Content[i], mapping.Content[i+1]
+		if keyNode.Tag == "!!merge" {
+			merged := []*yaml.Node{valNode}
+			if valNode.Kind == yaml.SequenceNode {
+				merged = valNode.Content
+			}
+			for _, node := range merged {
+				for node.Kind == yaml.AliasNode {
+					node = node.Alias
+				}
+				if node.Kind == yaml.MappingNode {
+					w.walkMapping(n, node, t, visit)
+				}
+			}
+			continue
+		}
+
+		child := w.childNode(n, t, keyNode.Value)
+		child.node, child.keyNode = valNode, keyNode
+		child.shadowed = child.field != nil && child.field.alias && named[child.field.goName]
+		w.walk(child, visit)
+	}
+}
+
+// walkXML visits elements in the xml content like walk, the root element is not visited,
+// and it returns the content with aliased elements renamed to their fields' names,
+// only names of elements are changed and the encoder keeps newlines, so lines in the content are kept
+func (w *nodeWalker) walkXML(content []byte, t reflect.Type, visit func(n *docNode) bool) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	enc := xml.NewEncoder(buf)
+
+	type element struct {
+		node   *docNode
+		name   xml.Name
+		counts map[string]int
+	}
+	stack := []*element{}
+	for {
+		offset := dec.InputOffset()
+		token, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch elem := token.(type) {
+		case xml.StartElement:
+			elem = elem.Copy()
+			name := elem.Name
+			if len(stack) == 0 {
+				stack = append(stack, &element{node: &docNode{typ: t}, name: name, counts: map[string]int{}})
+				token = elem
+				break
+			}
+
+			parent := stack[len(stack)-1]
+			child := &docNode{docPath: fieldPath(parent.node.docPath, name.Local), path: fieldPath(parent.node.path, name.Local)}
+			if parent.node.typ != nil {
+				parentType := derefType(parent.node.typ)
+				child = w.childNode(parent.node, parentType, name.Local)
+				child.docPath, child.docKeys = fieldPath(parent.node.docPath, name.Local), nil
+				// repeated elements are appended to slices
+				if child.typ != nil && isRepeated(child.typ) {
+					// elements named by the field and its aliases are appended to the same slice
+					countKey := name.Local
+					if child.field != nil {
+						countKey = child.field.goName
+					}
+					child.path = indexPath(child.path, parent.counts[countKey])
+					parent.counts[countKey]++
+					child.typ = tomlTableType(child.typ)
+				}
+				line, column := position(content, offset)
+				child.keyNode = &yaml.Node{Kind: yaml.ScalarNode, Value: name.Local, Line: line, Column: column}
+				if !visit(child) || child.unknown {
+					child.typ = nil
+				}
+				if child.field != nil && child.field.alias {
+					elem.Name.Local = child.field.canonical
+				}
+			}
+			stack = append(stack, &element{node: child, name: name, counts: map[string]int{}})
+			token = elem
+		case xml.EndElement:
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				elem.Name.Local = top.name.Local
+				if top.node.field != nil && top.node.field.alias {
+					elem.Name.Local = top.node.field.canonical
+				}
+				stack = stack[:len(stack)-1]
+			}
+			token = elem
+		}
+
+		if err = enc.EncodeToken(xml.CopyToken(token)); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isRepeated checks if values of the type t are decoded from repeated xml elements
+func isRepeated(t reflect.Type) bool {
+	t = derefType(t)
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !isOpaque(t)
+}
+
+// typeError returns the error if the value n can not be decoded to its type
+func (w *nodeWalker) typeError(n *docNode) *ParseError {
+	if n.typ == nil {
+		return nil
+	}
+	t := derefType(n.typ)
+	if isOpaque(t) {
+		// errors are reported by the type itself
+		return nil
+	}
+
+	switch n.node.Kind {
+	case yaml.MappingNode:
+		if kind := t.Kind(); kind != reflect.Struct && kind != reflect.Map {
+			return typeMismatch(n.node, t, n.path, "mapping")
+		}
+	case yaml.SequenceNode:
+		if kind := t.Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return typeMismatch(n.node, t, n.path, "sequence")
+		}
+	case yaml.ScalarNode:
+		if n.node.Tag == "!!null" {
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Array:
+			return typeMismatch(n.node, t, n.path, strconv.Quote(n.node.Value))
+		case reflect.Slice:
+			if t.Elem().Kind() != reflect.Uint8 {
+				return typeMismatch(n.node, t, n.path, strconv.Quote(n.node.Value))
+			}
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if err := n.node.Decode(reflect.New(t).Interface()); err != nil {
+				return typeMismatch(n.node, t, n.path, strconv.Quote(n.node.Value))
+			}
+		}
+	}
+	return nil
+}
+
+// findTypeError returns the first value in the value n which can not be decoded to its type
+func (w *nodeWalker) findTypeError(n *docNode) *ParseError {
+	var found *ParseError
+	w.walk(n, func(n *docNode) bool {
+		found = w.typeError(n)
+		return found == nil
+	})
+	return found
+}